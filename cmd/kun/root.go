@@ -5,9 +5,13 @@ import (
 	"github.com/spruce1698/kun/internal/command/wire"
 
 	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/internal/command/conf"
 	"github.com/spruce1698/kun/internal/command/create"
+	"github.com/spruce1698/kun/internal/command/keys"
 	"github.com/spruce1698/kun/internal/command/new"
 	"github.com/spruce1698/kun/internal/command/run"
+	"github.com/spruce1698/kun/internal/command/template"
+	"github.com/spruce1698/kun/internal/command/token"
 	"github.com/spruce1698/kun/internal/command/upgrade"
 )
 
@@ -16,6 +20,8 @@ var CmdRoot = &cobra.Command{
 	Example: "kun new demo",
 	Short:   config.Short,
 	Version: config.Short,
+	// 错误统一由 main 输出并以非 0 退出
+	SilenceErrors: true,
 }
 
 func init() {
@@ -30,6 +36,46 @@ func init() {
 	create.CmdCreate.AddCommand(create.CmdCreateRouter)
 	create.CmdCreate.AddCommand(create.CmdCreateDBRepository)
 	create.CmdCreate.AddCommand(create.CmdCreateCacheRepository)
+	create.CmdCreate.AddCommand(create.CmdCreateAll)
+	create.CmdCreate.AddCommand(create.CmdCreateAction)
+
+	CmdRoot.AddCommand(create.CmdRemove)
+	create.CmdRemove.AddCommand(create.CmdRemoveController)
+	create.CmdRemove.AddCommand(create.CmdRemoveService)
+	create.CmdRemove.AddCommand(create.CmdRemoveControllerAndService)
+	create.CmdRemove.AddCommand(create.CmdRemoveRouter)
+	create.CmdRemove.AddCommand(create.CmdRemoveDBRepository)
+	create.CmdRemove.AddCommand(create.CmdRemoveCacheRepository)
+
+	CmdRoot.AddCommand(create.CmdRename)
+	create.CmdRename.AddCommand(create.CmdRenameController)
+	create.CmdRename.AddCommand(create.CmdRenameService)
+	create.CmdRename.AddCommand(create.CmdRenameControllerAndService)
+	create.CmdRename.AddCommand(create.CmdRenameRouter)
+	create.CmdRename.AddCommand(create.CmdRenameDBRepository)
+	create.CmdRename.AddCommand(create.CmdRenameCacheRepository)
+
+	CmdRoot.AddCommand(template.CmdTemplate)
+	template.CmdTemplate.AddCommand(template.CmdTemplateAdd)
+	template.CmdTemplate.AddCommand(template.CmdTemplateList)
+	template.CmdTemplate.AddCommand(template.CmdTemplateRemove)
+	template.CmdTemplate.AddCommand(template.CmdTemplateUpdate)
+
+	CmdRoot.AddCommand(keys.CmdKeys)
+	keys.CmdKeys.AddCommand(keys.CmdKeysGen)
+	keys.CmdKeysGen.AddCommand(keys.CmdKeysGenRSA)
+	keys.CmdKeysGen.AddCommand(keys.CmdKeysGenHMAC)
+
+	CmdRoot.AddCommand(conf.CmdConfig)
+	conf.CmdConfig.AddCommand(conf.CmdConfigNew)
+	conf.CmdConfig.AddCommand(conf.CmdConfigDiff)
+	conf.CmdConfig.AddCommand(conf.CmdConfigValidate)
+	conf.CmdConfig.AddCommand(conf.CmdConfigEncrypt)
+	conf.CmdConfig.AddCommand(conf.CmdConfigDecrypt)
+
+	CmdRoot.AddCommand(token.CmdToken)
+	token.CmdToken.AddCommand(token.CmdTokenIssue)
+	token.CmdToken.AddCommand(token.CmdTokenDecode)
 
 	CmdRoot.AddCommand(wire.CmdWire)
 	wire.CmdWire.AddCommand(wire.CmdWireAll)
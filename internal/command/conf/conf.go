@@ -0,0 +1,250 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gopkg.in/yaml.v3"
+)
+
+// xconfig 支持的环境, 见 local.yml 中 env 的说明
+var envs = []string{"debug", "test", "staging", "release"}
+
+// 新建环境配置时重新生成的密钥
+var secretKeys = []string{"token.secret", "token.refreshSecret"}
+
+var (
+	dir         string
+	from        string
+	envValue    string
+	force       bool
+	keepSecrets bool
+	base        string
+	pkgDir      string
+	typeName    string
+
+	CmdConfig = &cobra.Command{
+		Use:     "config",
+		Short:   "Create, diff and validate the config files of each environment",
+		Example: "kun config new release\n  kun config diff\n  kun config validate config/release.yml",
+	}
+
+	CmdConfigNew = &cobra.Command{
+		Use:          "new [name]",
+		Short:        "Create config/<name>.yml from config/local.yml",
+		Example:      "kun config new release\n  kun config new prod --env release",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runNew,
+	}
+
+	CmdConfigDiff = &cobra.Command{
+		Use:          "diff",
+		Short:        "Report keys missing or extra in each config file compared with config/local.yml",
+		Example:      "kun config diff\n  kun config diff --base release",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         runDiff,
+	}
+
+	CmdConfigValidate = &cobra.Command{
+		Use:          "validate [file...]",
+		Short:        "Validate config files against the xconfig.Conf struct of the project, default all",
+		Example:      "kun config validate config/release.yml\n  kun config validate --pkg pkg/xconfig --type Conf",
+		SilenceUsage: true,
+		RunE:         runValidate,
+	}
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{CmdConfigNew, CmdConfigDiff, CmdConfigValidate} {
+		cmd.Flags().StringVarP(&dir, "dir", "d", "config", "config directory")
+	}
+	CmdConfigNew.Flags().StringVar(&from, "from", "local", "copy from config/<from>.yml")
+	CmdConfigNew.Flags().StringVarP(&envValue, "env", "e", envValue, "value of env in the new file: "+strings.Join(envs, "|")+", default is the name")
+	CmdConfigNew.Flags().BoolVarP(&force, "force", "f", force, "overwrite the existing file")
+	CmdConfigNew.Flags().BoolVar(&keepSecrets, "keep-secrets", keepSecrets, "keep "+strings.Join(secretKeys, ",")+" instead of generating new ones")
+	CmdConfigDiff.Flags().StringVarP(&base, "base", "b", "local", "compare the other files with config/<base>.yml")
+	CmdConfigValidate.Flags().StringVar(&pkgDir, "pkg", "pkg/xconfig", "package directory of the config struct")
+	CmdConfigValidate.Flags().StringVar(&typeName, "type", "Conf", "name of the config struct")
+}
+
+func runNew(_ *cobra.Command, args []string) error {
+	name := args[0]
+	if !filepath.IsLocal(name) || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid config name %s", name)
+	}
+	env := envValue
+	if env == "" {
+		env = name
+	}
+	if !slices.Contains(envs, env) {
+		return fmt.Errorf("env must be one of %s, use --env to set it", strings.Join(envs, "|"))
+	}
+
+	src := filepath.Join(dir, from+".yml")
+	dst := filepath.Join(dir, name+".yml")
+	if _, err := os.Stat(dst); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", dst)
+	}
+	data, err := os.ReadFile(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("config file %s not found, run it in the project root or use --dir", src)
+	}
+	if err != nil {
+		return err
+	}
+	f, err := helper.ParseYaml(data)
+	if err != nil {
+		return fmt.Errorf("parse %s error: %w", src, err)
+	}
+	if err = f.Set("env", env); err != nil {
+		return err
+	}
+	if !keepSecrets {
+		for _, key := range secretKeys {
+			if f.Lookup(key) == nil {
+				continue
+			}
+			secret, err := helper.RandomString(32)
+			if err != nil {
+				return err
+			}
+			if err = f.Set(key, secret); err != nil {
+				return err
+			}
+		}
+	}
+	if err = os.WriteFile(dst, f.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Success("Create %s from %s, env: %s", dst, src, env)
+	if !keepSecrets {
+		fmt.Print("%s are regenerated", strings.Join(secretKeys, ", "))
+	}
+	fmt.Warn("Review the addresses and passwords in %s before using it", dst)
+	return nil
+}
+
+func runDiff(_ *cobra.Command, _ []string) error {
+	files, err := configFiles()
+	if err != nil {
+		return err
+	}
+	basePath := filepath.Join(dir, base+".yml")
+	if !slices.Contains(files, basePath) {
+		return fmt.Errorf("config file %s not found, run it in the project root or use --dir", basePath)
+	}
+	baseKeys, err := fileKeys(basePath)
+	if err != nil {
+		return err
+	}
+
+	diff := false
+	for _, file := range files {
+		if file == basePath {
+			continue
+		}
+		keys, err := fileKeys(file)
+		if err != nil {
+			return err
+		}
+		missing, extra := compareKeys(baseKeys, keys)
+		if len(missing) == 0 && len(extra) == 0 {
+			fmt.Success("%s has the same keys as %s", file, basePath)
+			continue
+		}
+		diff = true
+		fmt.Error("%s differs from %s", file, basePath)
+		for _, k := range missing {
+			fmt.Print("missing: %s", k)
+		}
+		for _, k := range extra {
+			fmt.Print("extra:   %s", k)
+		}
+	}
+	if diff {
+		return fmt.Errorf("config files are out of sync")
+	}
+	return nil
+}
+
+// configFiles 配置目录下的 yml 文件, 不包含 xxx.example.yml
+func configFiles() ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yml" && ext != ".yaml") || strings.HasSuffix(e.Name(), ".example"+ext) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	return files, nil
+}
+
+// parseFile 解析 yml 文件, 返回顶层 mapping
+func parseFile(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := helper.ParseYaml(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", path, err)
+	}
+	return f.Root(), nil
+}
+
+// fileKeys 文件中所有叶子节点的路径, viper 不区分大小写, 以小写为键
+func fileKeys(path string) (map[string]string, error) {
+	root, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]string)
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+			if prefix != "" {
+				keys[strings.ToLower(prefix)] = prefix
+			}
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walk(node.Content[i+1], key)
+		}
+	}
+	walk(root, "")
+	return keys, nil
+}
+
+// compareKeys base 中有而 keys 中没有的为 missing, 反之为 extra
+func compareKeys(base, keys map[string]string) (missing, extra []string) {
+	for k, v := range base {
+		if _, ok := keys[k]; !ok {
+			missing = append(missing, v)
+		}
+	}
+	for k, v := range keys {
+		if _, ok := base[k]; !ok {
+			extra = append(extra, v)
+		}
+	}
+	slices.Sort(missing)
+	slices.Sort(extra)
+	return missing, extra
+}
@@ -0,0 +1,165 @@
+package conf
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	encryptKeys string
+	decryptKeys string
+	keyEnv      string
+
+	CmdConfigEncrypt = &cobra.Command{
+		Use:          "encrypt [file...]",
+		Short:        "Encrypt values in config files as ENC(...), xconfig.New decrypts them with the key in env " + helper.ConfKeyEnv,
+		Example:      "CONF_KEY=0123456789abcdef kun config encrypt config/release.yml\n  kun config encrypt config/release.yml --keys mysql.source.0,redis.password",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE:         runEncrypt,
+	}
+
+	CmdConfigDecrypt = &cobra.Command{
+		Use:          "decrypt [file...]",
+		Short:        "Decrypt ENC(...) values in config files back to plaintext",
+		Example:      "CONF_KEY=0123456789abcdef kun config decrypt config/release.yml\n  kun config decrypt config/release.yml --keys redis.password",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE:         runDecrypt,
+	}
+)
+
+func init() {
+	CmdConfigEncrypt.Flags().StringVarP(&encryptKeys, "keys", "k", "mysql.source,redis.password", "keys to encrypt, separated by comma, a mapping or sequence encrypts all values in it")
+	CmdConfigDecrypt.Flags().StringVarP(&decryptKeys, "keys", "k", decryptKeys, "keys to decrypt, separated by comma, default all ENC(...) values")
+	for _, cmd := range []*cobra.Command{CmdConfigEncrypt, CmdConfigDecrypt} {
+		cmd.Flags().StringVar(&keyEnv, "key-env", helper.ConfKeyEnv, "env holding the AES key, 16, 24 or 32 bytes")
+	}
+}
+
+func runEncrypt(_ *cobra.Command, args []string) error {
+	key, err := helper.ConfKey(keyEnv)
+	if err != nil {
+		return err
+	}
+	if keyEnv != helper.ConfKeyEnv {
+		fmt.Warn("xconfig.New reads the key from env %s, set it when running the project", helper.ConfKeyEnv)
+	}
+	return rewriteValues(args, encryptKeys, false, func(path, value string) (string, bool, error) {
+		if value == "" || helper.IsEncrypted(value) {
+			return "", false, nil
+		}
+		enc, err := helper.EncryptValue(value, key)
+		return enc, err == nil, err
+	}, "Encrypt")
+}
+
+func runDecrypt(_ *cobra.Command, args []string) error {
+	key, err := helper.ConfKey(keyEnv)
+	if err != nil {
+		return err
+	}
+	return rewriteValues(args, decryptKeys, true, func(path, value string) (string, bool, error) {
+		if !helper.IsEncrypted(value) {
+			return "", false, nil
+		}
+		plain, err := helper.DecryptValue(value, key)
+		if err != nil {
+			return "", false, fmt.Errorf("decrypt %s error: %w", path, err)
+		}
+		return plain, true, nil
+	}, "Decrypt")
+}
+
+// rewriteValues 修改 --keys 下的所有标量值, 未指定 --keys 时为整个文件, 保留文件的格式和注释
+// plain 为 true 时解密出的数字、布尔等按原样写入
+func rewriteValues(files []string, keys string, plain bool, change func(path, value string) (string, bool, error), action string) error {
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		f, err := helper.ParseYaml(data)
+		if err != nil {
+			return fmt.Errorf("parse %s error: %w", file, err)
+		}
+
+		var paths []string
+		if keys == "" {
+			paths = scalarPaths(f.Root(), "")
+		}
+		for _, k := range strings.Split(keys, ",") {
+			if k = strings.TrimSpace(k); k == "" {
+				continue
+			}
+			node := f.Lookup(k)
+			if node == nil {
+				fmt.Warn("%s: %s not found, skipped", file, k)
+				continue
+			}
+			paths = append(paths, scalarPaths(node, k)...)
+		}
+
+		var changed []string
+		for _, path := range paths {
+			value, ok, err := change(path, f.Lookup(path).Value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			if !ok {
+				continue
+			}
+			set := f.Set
+			if plain {
+				set = f.SetPlain
+			}
+			if err = set(path, value); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			changed = append(changed, path)
+		}
+		if len(changed) == 0 {
+			fmt.Print("%s: nothing to %s", file, strings.ToLower(action))
+			continue
+		}
+		if err = os.WriteFile(file, f.Bytes(), stat.Mode().Perm()); err != nil {
+			return err
+		}
+		fmt.Success("%s %s: %s", action, file, strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// scalarPaths 节点下所有标量的路径, 数组元素使用下标
+func scalarPaths(node *yaml.Node, path string) []string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return nil
+		}
+		return []string{path}
+	case yaml.MappingNode:
+		var paths []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			paths = append(paths, scalarPaths(node.Content[i+1], join(path, node.Content[i].Value))...)
+		}
+		return paths
+	case yaml.SequenceNode:
+		var paths []string
+		for i, c := range node.Content {
+			paths = append(paths, scalarPaths(c, join(path, strconv.Itoa(i)))...)
+		}
+		return paths
+	}
+	return nil
+}
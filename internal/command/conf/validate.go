@@ -0,0 +1,302 @@
+package conf
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gopkg.in/yaml.v3"
+)
+
+// field 配置结构体中的字段, 与 viper 一样按 mapstructure tag 或字段名匹配, 不区分大小写
+type field struct {
+	Name string
+	Type ast.Expr
+}
+
+// schema 由项目中的 Go 类型生成, 只解析 pkgDir 下的文件
+type schema struct {
+	types map[string]ast.Expr
+}
+
+func loadSchema() (*schema, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), pkgDir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", pkgDir, err)
+	}
+	s := &schema{types: make(map[string]ast.Expr)}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					s.types[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+	}
+	if _, ok := s.types[typeName]; !ok {
+		return nil, fmt.Errorf("type %s not found in %s, run it in the project root or use --pkg and --type", typeName, pkgDir)
+	}
+	return s, nil
+}
+
+// resolve 展开包内的类型名和指针
+func (s *schema) resolve(expr ast.Expr) ast.Expr {
+	for i := 0; i < 32; i++ {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.Ident:
+			named, ok := s.types[t.Name]
+			if !ok {
+				return t
+			}
+			expr = named
+		default:
+			return expr
+		}
+	}
+	return expr
+}
+
+// fields 结构体的字段, 匿名字段带 squash 时展开到上一级
+func (s *schema) fields(st *ast.StructType) []field {
+	var fields []field
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			if v, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag = reflect.StructTag(v)
+			}
+		}
+		name, opts, _ := strings.Cut(tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		if len(f.Names) == 0 {
+			if strings.Contains(opts, "squash") {
+				if embedded, ok := s.resolve(f.Type).(*ast.StructType); ok {
+					fields = append(fields, s.fields(embedded)...)
+					continue
+				}
+			}
+			if name == "" {
+				name = typeIdent(f.Type)
+			}
+			fields = append(fields, field{Name: name, Type: f.Type})
+			continue
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			fn := name
+			if fn == "" {
+				fn = n.Name
+			}
+			fields = append(fields, field{Name: fn, Type: f.Type})
+		}
+	}
+	return fields
+}
+
+func typeIdent(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return typeIdent(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// problem 校验结果, Warn 为未配置的字段, 使用零值
+type problem struct {
+	Path string
+	Msg  string
+	Line int
+	Warn bool
+}
+
+// check 按 viper 的弱类型转换规则校验节点
+func (s *schema) check(expr ast.Expr, node *yaml.Node, path string, problems *[]problem) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	mismatch := func(want string) {
+		*problems = append(*problems, problem{Path: path, Line: node.Line, Msg: fmt.Sprintf("expect %s, got %s", want, kindName(node))})
+	}
+
+	switch t := s.resolve(expr).(type) {
+	case *ast.StructType:
+		if node.Kind != yaml.MappingNode {
+			mismatch("a mapping")
+			return
+		}
+		fields := s.fields(t)
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			sub := join(path, key)
+			idx := slices.IndexFunc(fields, func(f field) bool { return strings.EqualFold(f.Name, key) })
+			if idx < 0 {
+				*problems = append(*problems, problem{Path: sub, Line: node.Content[i].Line, Msg: "unknown key, not a field of " + typeName})
+				continue
+			}
+			seen[strings.ToLower(fields[idx].Name)] = true
+			s.check(fields[idx].Type, node.Content[i+1], sub, problems)
+		}
+		for _, f := range fields {
+			if !seen[strings.ToLower(f.Name)] {
+				*problems = append(*problems, problem{Path: join(path, lowerFirst(f.Name)), Line: node.Line, Msg: "not set, the zero value is used", Warn: true})
+			}
+		}
+	case *ast.MapType:
+		if node.Kind != yaml.MappingNode {
+			mismatch("a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			s.check(t.Value, node.Content[i+1], join(path, node.Content[i].Value), problems)
+		}
+	case *ast.ArrayType:
+		switch node.Kind {
+		case yaml.SequenceNode:
+			for i, c := range node.Content {
+				s.check(t.Elt, c, path+"."+strconv.Itoa(i), problems)
+			}
+		case yaml.ScalarNode:
+			// 弱类型转换, 单个值视为只有一个元素的数组
+			s.check(t.Elt, node, path, problems)
+		default:
+			mismatch("a sequence")
+		}
+	case *ast.Ident:
+		if node.Kind != yaml.ScalarNode {
+			mismatch(t.Name)
+			return
+		}
+		if !scalarFits(t.Name, node) {
+			mismatch(t.Name)
+		}
+	case *ast.SelectorExpr:
+		// 其他包的类型只校验常见的 time.Duration
+		if typeIdent(t) == "Duration" && node.Kind != yaml.ScalarNode {
+			mismatch("a duration")
+		}
+	}
+}
+
+// scalarFits 标量能否转换为基础类型
+func scalarFits(kind string, node *yaml.Node) bool {
+	v := node.Value
+	switch {
+	case kind == "string", helper.IsEncrypted(v):
+		// ENC(...) 在 xconfig 中解密后再转换
+		return true
+	case kind == "bool":
+		if node.Tag == "!!int" || node.Tag == "!!float" || v == "" {
+			return true
+		}
+		_, err := strconv.ParseBool(v)
+		return err == nil
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		if node.Tag == "!!bool" || v == "" {
+			return true
+		}
+		if _, err := strconv.ParseInt(v, 0, 64); err == nil {
+			return true
+		}
+		_, err := strconv.ParseFloat(v, 64)
+		return err == nil
+	}
+	// any 和包外的类型不校验
+	return true
+}
+
+func kindName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a sequence"
+	}
+	return strconv.Quote(node.Value)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func runValidate(_ *cobra.Command, args []string) error {
+	s, err := loadSchema()
+	if err != nil {
+		return err
+	}
+	files := args
+	if len(files) == 0 {
+		if files, err = configFiles(); err != nil {
+			return err
+		}
+	}
+
+	invalid := 0
+	for _, file := range files {
+		root, err := parseFile(file)
+		if err != nil {
+			return err
+		}
+		var problems []problem
+		s.check(&ast.Ident{Name: typeName}, root, "", &problems)
+		errs := slices.DeleteFunc(slices.Clone(problems), func(p problem) bool { return p.Warn })
+		if len(errs) == 0 {
+			fmt.Success("%s is valid", file)
+		} else {
+			invalid++
+			fmt.Error("%s is invalid", file)
+		}
+		for _, p := range problems {
+			if p.Warn {
+				fmt.Warn("%s:%d %s: %s", file, p.Line, p.Path, p.Msg)
+			} else {
+				fmt.Print("%s:%d %s: %s", file, p.Line, p.Path, p.Msg)
+			}
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d config file(s) do not match %s.%s", invalid, pkgDir, typeName)
+	}
+	return nil
+}
@@ -0,0 +1,572 @@
+package create
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/internal/command/create/kernel"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+var (
+	actionMethod string
+	actionPath   string
+
+	CmdCreateAction = &cobra.Command{
+		Use:          "action",
+		Short:        "Add a controller action, service method and route to existing components",
+		Example:      "kun create action user Login --method POST --path /user/login",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runCreateAction,
+	}
+)
+
+func init() {
+	CmdCreateAction.Flags().StringVarP(&actionMethod, "method", "m", "GET", "HTTP method of the route, GET/POST/PUT/PATCH/DELETE/HEAD/OPTIONS/Any")
+	CmdCreateAction.Flags().StringVarP(&actionPath, "path", "p", actionPath, "route path, default /<name>/<action>")
+}
+
+// 路由注册的方法, key 为大写
+var routeMethods = map[string]string{
+	"GET":     "GET",
+	"POST":    "POST",
+	"PUT":     "PUT",
+	"PATCH":   "PATCH",
+	"DELETE":  "DELETE",
+	"HEAD":    "HEAD",
+	"OPTIONS": "OPTIONS",
+	"ANY":     "Any",
+}
+
+// actionEditor 在已有的 controller、service 和 router 中添加方法
+type actionEditor struct {
+	projectName string
+	dir         string // 组件所在的子目录
+	name        string // 组件名, 如 User
+	action      string // 方法名, 如 Login
+	method      string
+	path        string
+
+	files map[string]*kernel.GoFile
+}
+
+// 已解析的类型
+type typeMatch struct {
+	file *kernel.GoFile
+	decl *ast.GenDecl
+	spec *ast.TypeSpec
+}
+
+// 已解析的方法
+type methodMatch struct {
+	file *kernel.GoFile
+	decl *ast.FuncDecl
+}
+
+func runCreateAction(_ *cobra.Command, args []string) error {
+	e := &actionEditor{files: make(map[string]*kernel.GoFile)}
+	e.projectName = helper.GetProjectName(".")
+	if e.projectName == "" {
+		return fmt.Errorf("run it in the project root")
+	}
+	e.dir, e.name = filepath.Split(args[0])
+	var err error
+	if e.name, err = helper.GoName(strings.TrimSuffix(e.name, ".go")); err != nil {
+		return err
+	}
+	if e.action, err = helper.GoName(args[1]); err != nil {
+		return fmt.Errorf("invalid action: %w", err)
+	}
+	method, ok := routeMethods[strings.ToUpper(actionMethod)]
+	if !ok {
+		return fmt.Errorf("invalid method %s", actionMethod)
+	}
+	e.method = method
+	e.path = actionPath
+	if e.path == "" {
+		e.path = "/" + helper.LowerCamelCase(e.name) + "/" + helper.LowerCamelCase(e.action)
+	}
+
+	ctrl, svcField, svcType, err := e.addController()
+	if err != nil {
+		return err
+	}
+	if svcType != nil {
+		if err = e.addService(ctrl, svcField, svcType); err != nil {
+			return err
+		}
+	} else {
+		fmt.Warn("%sCtrl has no %sSvc field, the service method is not added", e.name, e.name)
+	}
+	if err = e.addRoute(ctrl); err != nil {
+		return err
+	}
+
+	// 全部检查通过后再写入
+	var changed []*kernel.GoFile
+	for _, f := range e.files {
+		if f.Changed() {
+			if _, err = f.Output(); err != nil {
+				return err
+			}
+			changed = append(changed, f)
+		}
+	}
+	slices.SortFunc(changed, func(a, b *kernel.GoFile) int { return strings.Compare(a.Path, b.Path) })
+	for _, f := range changed {
+		if err = f.Write(); err != nil {
+			return err
+		}
+		fmt.Success("add %s to %s", e.action, f.Path)
+	}
+	return nil
+}
+
+// load 解析目录, 同一文件只解析一次
+func (e *actionEditor) load(dir string, recursive bool) ([]*kernel.GoFile, error) {
+	files, err := kernel.ParseGoDir(dir, recursive)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		if cached, ok := e.files[f.Path]; ok {
+			files[i] = cached
+		} else {
+			e.files[f.Path] = f
+		}
+	}
+	return files, nil
+}
+
+// addController 添加控制器方法, 返回控制器和其中 XxxSvc 字段的名称和类型
+func (e *actionEditor) addController() (*typeMatch, string, ast.Expr, error) {
+	ctrlName := e.name + "Ctrl"
+	dir := filepath.Join(BasePath, TypeController, e.dir)
+	files, err := e.load(dir, true)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	matches := findTypes(files, ctrlName)
+	if len(matches) == 0 {
+		return nil, "", nil, fmt.Errorf("%s not found in %s, run kun create ctrl first", ctrlName, dir)
+	}
+	if len(matches) > 1 {
+		return nil, "", nil, fmt.Errorf("%s found in %s and %s, use dir/name", ctrlName, matches[0].file.Path, matches[1].file.Path)
+	}
+	ctrl := matches[0]
+	st, ok := ctrl.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, "", nil, fmt.Errorf("%s in %s is not a struct", ctrlName, ctrl.file.Path)
+	}
+	methods := findMethods(sameDir(files, ctrl.file), ctrlName)
+	if slices.ContainsFunc(methods, func(m methodMatch) bool { return m.decl.Name.Name == e.action }) {
+		return nil, "", nil, fmt.Errorf("%s.%s already exists", ctrlName, e.action)
+	}
+
+	var (
+		svcField string
+		svcType  ast.Expr
+	)
+	for _, f := range st.Fields.List {
+		if typeName(f.Type) != e.name+"Svc" {
+			continue
+		}
+		svcType = f.Type
+		svcField = e.name + "Svc"
+		if len(f.Names) > 0 {
+			svcField = f.Names[0].Name
+		}
+		break
+	}
+
+	recv := receiverName(methods, e.name)
+	var body string
+	if svcType != nil {
+		body = fmt.Sprintf(`	data, err := %s.%s.%s(ctx.Request.Context())
+	if err != nil {
+		xhttp.BusFail(ctx, err)
+		return
+	}
+	xhttp.Data(ctx, "%s %s success", data)`, recv, svcField, e.action, e.name, e.action)
+	} else {
+		body = fmt.Sprintf(`	// TODO: add your code here and delete this line
+	xhttp.Data(ctx, "%s %s success", nil)`, e.name, e.action)
+	}
+	code := fmt.Sprintf(`
+
+func (%s *%s) %s(ctx *gin.Context) {
+	req := &struct {
+		// TODO: add your code here and delete this line
+	}{}
+	if err := ctx.ShouldBind(req); err != nil {
+		xhttp.BusCode(ctx, xerror.ParamError, err)
+		return
+	}
+%s
+}`, recv, ctrlName, e.action, body)
+	ctrl.file.Insert(insertPos(ctrl, methods), code)
+	ctrl.file.AddImport("github.com/gin-gonic/gin")
+	ctrl.file.AddImport(e.projectName + "/pkg/xhttp")
+	ctrl.file.AddImport(e.projectName + "/pkg/xerror")
+	return ctrl, svcField, svcType, nil
+}
+
+// addService 在 service 接口和实现中添加方法
+func (e *actionEditor) addService(ctrl *typeMatch, svcField string, svcType ast.Expr) error {
+	dir := filepath.Dir(ctrl.file.Path)
+	if sel, ok := unstar(svcType).(*ast.SelectorExpr); ok {
+		path := ctrl.file.ImportPath(sel.X.(*ast.Ident).Name)
+		if !strings.HasPrefix(path, e.projectName+"/") {
+			return fmt.Errorf("%s.%s is not a service of this project", ctrl.spec.Name.Name, svcField)
+		}
+		dir = filepath.FromSlash(strings.TrimPrefix(path, e.projectName+"/"))
+	}
+	svcName := typeName(svcType)
+	files, err := e.load(dir, false)
+	if err != nil {
+		return err
+	}
+	matches := findTypes(files, svcName)
+	if len(matches) == 0 {
+		return fmt.Errorf("%s not found in %s", svcName, dir)
+	}
+	iface, ok := matches[0].spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return fmt.Errorf("%s in %s is not an interface", svcName, matches[0].file.Path)
+	}
+	for _, m := range iface.Methods.List {
+		if slices.ContainsFunc(m.Names, func(n *ast.Ident) bool { return n.Name == e.action }) {
+			return fmt.Errorf("%s.%s already exists", svcName, e.action)
+		}
+	}
+
+	implName := implOf(files, svcName)
+	impls := findTypes(files, implName)
+	if len(impls) == 0 {
+		return fmt.Errorf("the implementation of %s not found in %s", svcName, dir)
+	}
+	impl := impls[0]
+	methods := findMethods(files, implName)
+	if slices.ContainsFunc(methods, func(m methodMatch) bool { return m.decl.Name.Name == e.action }) {
+		return fmt.Errorf("%s.%s already exists", implName, e.action)
+	}
+
+	matches[0].file.Insert(iface.Methods.Closing, e.action+"(ctx context.Context) (any, error)\n")
+	matches[0].file.AddImport("context")
+	code := fmt.Sprintf(`
+
+func (%s *%s) %s(ctx context.Context) (any, error) {
+	// TODO: add your code here and delete this line
+	return nil, nil
+}`, receiverName(methods, implName), implName, e.action)
+	impl.file.Insert(insertPos(impl, methods), code)
+	impl.file.AddImport("context")
+	return nil
+}
+
+// addRoute 在已注册该控制器的路由函数中添加路由, 没有时使用与组件同名的路由函数
+func (e *actionEditor) addRoute(ctrl *typeMatch) error {
+	field, err := e.ctxField(ctrl)
+	if err != nil {
+		return err
+	}
+	handler := e.method + "(" + strconv.Quote(e.path) + ", ctx." + field + "." + e.action + ")"
+	if field == "" {
+		fmt.Warn("%s is not in controller.ServerCtrlCtx, add the route manually: %s", ctrl.spec.Name.Name, handler)
+		return nil
+	}
+
+	dir := filepath.Join(BasePath, TypeRouter)
+	files, err := e.load(dir, true)
+	if err != nil {
+		return err
+	}
+	var routers, named []*routerFunc
+	for _, f := range files {
+		for _, decl := range f.File.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			r := newRouterFunc(f, fd, field)
+			if r == nil {
+				continue
+			}
+			if r.last != nil {
+				routers = append(routers, r)
+			} else if fd.Name.Name == e.name && filepath.Dir(f.Path) == filepath.Join(dir, e.dir) {
+				named = append(named, r)
+			}
+		}
+	}
+	// 优先使用与组件同名的函数
+	slices.SortStableFunc(routers, func(a, b *routerFunc) int {
+		return boolInt(b.decl.Name.Name == e.name) - boolInt(a.decl.Name.Name == e.name)
+	})
+	routers = append(routers, named...)
+	if len(routers) == 0 {
+		fmt.Warn("no router function uses ctx.%s, add the route manually: %s", field, handler)
+		return nil
+	}
+
+	r := routers[0]
+	if r.exists(e.action) {
+		fmt.Warn("route to ctx.%s.%s already exists in %s", field, e.action, r.file.Path)
+		return nil
+	}
+	if r.last != nil {
+		sel := r.last.X.(*ast.CallExpr).Fun.(*ast.SelectorExpr)
+		handler = r.file.Text(sel.X) + "." + strings.ReplaceAll(handler, "ctx.", r.ctx+".")
+		r.file.Insert(r.last.End(), "\n"+handler)
+		return nil
+	}
+	if r.engine == "" {
+		fmt.Warn("%s has no *gin.Engine parameter, add the route manually: %s", r.decl.Name.Name, handler)
+		return nil
+	}
+	handler = r.engine + "." + strings.ReplaceAll(handler, "ctx.", r.ctx+".")
+	r.file.Insert(r.decl.Body.Rbrace, handler+"\n")
+	return nil
+}
+
+// ctxField 控制器在 controller.ServerCtrlCtx 中的字段名
+func (e *actionEditor) ctxField(ctrl *typeMatch) (string, error) {
+	files, err := e.load(filepath.Join(BasePath, TypeController), false)
+	if err != nil {
+		return "", err
+	}
+	ctrlDir := filepath.Dir(ctrl.file.Path)
+	for _, m := range findTypes(files, "ServerCtrlCtx") {
+		st, ok := m.spec.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, f := range st.Fields.List {
+			if typeName(f.Type) != ctrl.spec.Name.Name {
+				continue
+			}
+			switch t := unstar(f.Type).(type) {
+			case *ast.Ident:
+				if filepath.Dir(m.file.Path) != ctrlDir {
+					continue
+				}
+			case *ast.SelectorExpr:
+				if m.file.ImportPath(t.X.(*ast.Ident).Name) != e.projectName+"/"+filepath.ToSlash(ctrlDir) {
+					continue
+				}
+			}
+			if len(f.Names) == 0 {
+				return ctrl.spec.Name.Name, nil
+			}
+			return f.Names[0].Name, nil
+		}
+	}
+	return "", nil
+}
+
+// routerFunc 参数为 (e *gin.Engine, jwt *token.Jwt, ctx *controller.ServerCtrlCtx) 的路由函数
+type routerFunc struct {
+	file   *kernel.GoFile
+	decl   *ast.FuncDecl
+	engine string // *gin.Engine 参数名
+	ctx    string // *controller.ServerCtrlCtx 参数名
+	field  string
+	routes []*ast.ExprStmt // 使用该控制器的路由
+	last   *ast.ExprStmt
+}
+
+func newRouterFunc(f *kernel.GoFile, fd *ast.FuncDecl, field string) *routerFunc {
+	r := &routerFunc{file: f, decl: fd, field: field}
+	for _, p := range fd.Type.Params.List {
+		if len(p.Names) == 0 {
+			continue
+		}
+		switch typeName(p.Type) {
+		case "Engine":
+			r.engine = p.Names[0].Name
+		case "ServerCtrlCtx":
+			r.ctx = p.Names[0].Name
+		}
+	}
+	if r.ctx == "" {
+		return nil
+	}
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.ExprStmt)
+		if !ok {
+			return true
+		}
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || routeMethods[strings.ToUpper(sel.Sel.Name)] != sel.Sel.Name {
+			return true
+		}
+		for _, arg := range call.Args {
+			if r.handler(arg) != "" {
+				r.routes = append(r.routes, stmt)
+				r.last = stmt
+				break
+			}
+		}
+		return true
+	})
+	return r
+}
+
+// handler ctx.XxxCtrl.Action 形式的参数中的方法名
+func (r *routerFunc) handler(expr ast.Expr) string {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	inner, ok := sel.X.(*ast.SelectorExpr)
+	if !ok || inner.Sel.Name != r.field {
+		return ""
+	}
+	if id, ok := inner.X.(*ast.Ident); !ok || id.Name != r.ctx {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+func (r *routerFunc) exists(action string) bool {
+	for _, stmt := range r.routes {
+		for _, arg := range stmt.X.(*ast.CallExpr).Args {
+			if r.handler(arg) == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findTypes 查找类型定义
+func findTypes(files []*kernel.GoFile, name string) []*typeMatch {
+	var matches []*typeMatch
+	for _, f := range files {
+		for _, decl := range f.File.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+					matches = append(matches, &typeMatch{file: f, decl: gen, spec: ts})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// findMethods 查找接收者为 recv 的方法
+func findMethods(files []*kernel.GoFile, recv string) []methodMatch {
+	var methods []methodMatch
+	for _, f := range files {
+		for _, decl := range f.File.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if ok && fd.Recv != nil && len(fd.Recv.List) > 0 && typeName(fd.Recv.List[0].Type) == recv {
+				methods = append(methods, methodMatch{file: f, decl: fd})
+			}
+		}
+	}
+	return methods
+}
+
+// implOf 由 var _ XxxSvc = (*xxxSvc)(nil) 查找接口的实现, 没有时按命名约定
+func implOf(files []*kernel.GoFile, iface string) string {
+	for _, f := range files {
+		for _, decl := range f.File.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if vs.Type == nil || typeName(vs.Type) != iface || len(vs.Values) != 1 {
+					continue
+				}
+				if call, ok := vs.Values[0].(*ast.CallExpr); ok {
+					if paren, ok := call.Fun.(*ast.ParenExpr); ok {
+						return typeName(paren.X)
+					}
+				}
+			}
+		}
+	}
+	return lowerFirst(iface)
+}
+
+// insertPos 新方法的位置, 类型所在文件中最后一个方法之后, 没有方法时在类型定义之后
+func insertPos(t *typeMatch, methods []methodMatch) token.Pos {
+	pos := t.decl.End()
+	for _, m := range methods {
+		if m.file == t.file && m.decl.End() > pos {
+			pos = m.decl.End()
+		}
+	}
+	return pos
+}
+
+// receiverName 沿用已有方法的接收者名称
+func receiverName(methods []methodMatch, typeName string) string {
+	for _, m := range methods {
+		if names := m.decl.Recv.List[0].Names; len(names) > 0 && names[0].Name != "_" {
+			return names[0].Name
+		}
+	}
+	return strings.ToLower(typeName[:1])
+}
+
+func sameDir(files []*kernel.GoFile, file *kernel.GoFile) []*kernel.GoFile {
+	var result []*kernel.GoFile
+	for _, f := range files {
+		if filepath.Dir(f.Path) == filepath.Dir(file.Path) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func unstar(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return expr
+}
+
+// typeName 去掉指针和包名后的类型名
+func typeName(expr ast.Expr) string {
+	switch t := unstar(expr).(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return typeName(t.X)
+	}
+	return ""
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
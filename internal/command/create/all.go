@@ -0,0 +1,285 @@
+package create
+
+import (
+	"go/ast"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/internal/command/create/kernel"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	dsn string
+
+	CmdCreateAll = &cobra.Command{
+		Use:          "all",
+		Short:        "Create a CRUD db repository, service, controller and router for a table",
+		Example:      "kun create all user\n  kun create all user_info --dsn \"name:pwd@tcp(127.0.0.1:3306)/dbname\"",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runCreateAll,
+	}
+)
+
+func init() {
+	CmdCreateAll.Flags().StringVarP(&tplPath, "tpl-path", "t", tplPath, "template path")
+	CmdCreateAll.Flags().StringVar(&dsn, "dsn", dsn, "generate the db repository from the table first, otherwise use the generated XxxDb in "+DefaultOutPath)
+}
+
+// Crud 由 db repository 的结构体生成增删改查代码的数据
+type Crud struct {
+	Table  string       // 表名
+	PkType string       // 主键类型
+	Find   string       // 查询单条的方法, Find 或旧版本生成的 FindOne
+	List   string       // 分页查询的方法, ListWithTotal 或 FindListWithTotal
+	Remove string       // 删除的方法, 有 deleted_at 时为 SoftDelete
+	Fields []*CrudField // 除 deleted_at 外的字段
+}
+
+// CrudField 结构体字段, Editable 为 Create/Update 可以修改的字段
+type CrudField struct {
+	Name     string
+	Type     string
+	Column   string
+	JSON     string
+	Comment  string
+	Required bool
+	Editable bool
+}
+
+// 不允许通过接口修改的列
+var readonlyColumns = []string{"id", "created_at", "updated_at", "deleted_at"}
+
+// EditableFields Create/Update 的请求字段
+func (c *Crud) EditableFields() []*CrudField {
+	var fields []*CrudField
+	for _, f := range c.Fields {
+		if f.Editable {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Columns Update 时更新的列, 零值也会更新
+func (c *Crud) Columns() string {
+	var columns []string
+	for _, f := range c.EditableFields() {
+		columns = append(columns, strconv.Quote(f.Column))
+	}
+	return strings.Join(columns, ", ")
+}
+
+func runCreateAll(_ *cobra.Command, args []string) error {
+	projectName := helper.GetProjectName(".")
+	if projectName == "" {
+		return fmt.Errorf("run it in the project root")
+	}
+	name := strings.TrimSuffix(args[0], "Db")
+	if strings.ContainsAny(name, `/\.`) {
+		return fmt.Errorf("invalid table name %s", args[0])
+	}
+	naming := schema.NamingStrategy{SingularTable: true}
+	structName := strings.ReplaceAll(naming.SchemaName(name), "ID", "Id")
+	if _, err := helper.GoName(structName); err != nil {
+		return err
+	}
+	if dsn != "" {
+		if err := generateDBRepo(&CmdParams{DSN: dsn, DBType: string(dbMySQL), OutPath: DefaultOutPath, Tables: []string{name}}); err != nil {
+			return err
+		}
+	}
+
+	crud, err := parseRepo(DefaultOutPath, structName)
+	if err != nil {
+		return err
+	}
+
+	c := NewCreate()
+	c.ProjectName = projectName
+	c.CmdType = "all"
+	c.FileName = structName
+	c.FileNameTitleLower = helper.LowerCamelCase(structName)
+	c.FileNameFirstChar = c.FileNameTitleLower[:1]
+	c.Crud = crud
+
+	targets := map[string]string{
+		TypeService:    filepath.Join(BasePath, genConfigs[TypeService].typePath, c.FileNameTitleLower+".go"),
+		TypeController: filepath.Join(BasePath, genConfigs[TypeController].typePath, c.FileNameTitleLower+".go"),
+		TypeRouter:     filepath.Join(BasePath, genConfigs[TypeRouter].typePath, c.FileNameTitleLower+".go"),
+	}
+	for _, t := range []string{TypeService, TypeController, TypeRouter} {
+		if kernel.Exists(targets[t]) {
+			return fmt.Errorf("%s already exists, nothing was created", targets[t])
+		}
+	}
+
+	// 先渲染全部组件并检查 DI 文件, 都成功后再写入, 失败时不留下部分文件
+	// repository 已生成时确保已注册到 DI
+	repoDI := []kernel.DIEntry{
+		{Kind: kernel.DISet, Target: "WireServerSet", Code: "db.New" + structName + "Db", Import: projectName + "/" + filepath.ToSlash(filepath.Clean(DefaultOutPath)), Marker: "Add Repo before this line"},
+	}
+	repoFiles, err := kernel.PlanDI(filepath.Dir(DefaultOutPath), repoDI)
+	if err != nil {
+		return fmt.Errorf("insert New%sDb to DI file error: %w", structName, err)
+	}
+	var gens []*generation
+	for _, t := range []string{TypeService, TypeController, TypeRouter} {
+		c.CreateType = t
+		c.TplName = "crud" + strings.ToUpper(t[:1]) + t[1:]
+		g, err := c.plan()
+		if err != nil {
+			return fmt.Errorf("create %s error: %w", t, err)
+		}
+		if g == nil {
+			return fmt.Errorf("create %s error: the file already exists, nothing was created", t)
+		}
+		gens = append(gens, g)
+	}
+
+	for _, f := range repoFiles {
+		if err = f.Write(); err != nil {
+			return fmt.Errorf("insert New%sDb to DI file error: %w", structName, err)
+		}
+	}
+	for _, g := range gens {
+		if err = g.write(); err != nil {
+			return fmt.Errorf("create %s error: %w", g.createType, err)
+		}
+	}
+	fmt.Success("Create %s CRUD done, run `kun wire all` to regenerate wire_gen.go", structName)
+	return nil
+}
+
+// parseRepo 解析 db 目录下生成的结构体和 XxxDb 接口的方法
+func parseRepo(dir, structName string) (*Crud, error) {
+	files, err := kernel.ParseGoDir(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	interfaceName := strings.ToLower(structName[:1]) + structName[1:] + "Db"
+	var (
+		st      *ast.StructType
+		methods []string
+		table   string
+	)
+	for _, f := range files {
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.TypeSpec:
+				switch t := n.Type.(type) {
+				case *ast.StructType:
+					if n.Name.Name == structName {
+						st = t
+					}
+				case *ast.InterfaceType:
+					if n.Name.Name == structName+"Db" || n.Name.Name == interfaceName {
+						for _, m := range t.Methods.List {
+							for _, name := range m.Names {
+								methods = append(methods, name.Name)
+							}
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				for i, name := range n.Names {
+					if (name.Name == "Table"+structName || name.Name == "TableName"+structName) && i < len(n.Values) {
+						if lit, ok := n.Values[i].(*ast.BasicLit); ok {
+							table, _ = strconv.Unquote(lit.Value)
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	if st == nil {
+		return nil, fmt.Errorf("struct %s not found in %s, generate it with --dsn or kun create db first", structName, dir)
+	}
+
+	crud := &Crud{Table: table}
+	pick := func(names ...string) string {
+		for _, name := range names {
+			if slices.Contains(methods, name) {
+				return name
+			}
+		}
+		return ""
+	}
+	crud.Find, crud.List = pick("Find", "FindOne"), pick("ListWithTotal", "FindListWithTotal")
+	for _, m := range [][2]string{{crud.Find, "Find"}, {crud.List, "ListWithTotal"}, {pick("Insert"), "Insert"}, {pick("Update"), "Update"}} {
+		if m[0] == "" {
+			return nil, fmt.Errorf("%sDb has no %s method", structName, m[1])
+		}
+	}
+
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			continue
+		}
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			if v, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag = reflect.StructTag(v)
+			}
+		}
+		gormTag := tag.Get("gorm")
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			field := &CrudField{
+				Name:    n.Name,
+				Type:    types.ExprString(f.Type),
+				Column:  gormSetting(gormTag, "column"),
+				JSON:    strings.Split(tag.Get("json"), ",")[0],
+				Comment: strings.TrimSpace(f.Comment.Text()),
+			}
+			if field.Column == "" {
+				field.Column = schema.NamingStrategy{}.ColumnName("", n.Name)
+			}
+			if field.JSON == "" {
+				field.JSON = strings.ToLower(n.Name[:1]) + n.Name[1:]
+			}
+			if strings.Contains(gormTag, "primaryKey") || field.Column == "id" {
+				crud.PkType = field.Type
+			}
+			if field.Column == "deleted_at" || field.Type == "gorm.DeletedAt" {
+				crud.Remove = "SoftDelete"
+				continue
+			}
+			field.Editable = !slices.Contains(readonlyColumns, field.Column) && !strings.Contains(gormTag, "primaryKey")
+			// 字符串非空且没有默认值时必填
+			field.Required = field.Editable && field.Type == "string" && strings.Contains(gormTag, "not null") && gormSetting(gormTag, "default") == ""
+			crud.Fields = append(crud.Fields, field)
+		}
+	}
+	if crud.PkType == "" || !slices.ContainsFunc(crud.Fields, func(f *CrudField) bool { return f.Name == "Id" }) {
+		return nil, fmt.Errorf("struct %s has no primary key Id", structName)
+	}
+	if crud.Remove == "" || pick(crud.Remove) == "" {
+		crud.Remove = pick("Delete", "SoftDelete")
+	}
+	if crud.Remove == "" {
+		return nil, fmt.Errorf("%sDb has no Delete method", structName)
+	}
+	return crud, nil
+}
+
+// gormSetting gorm tag 中 key:value 的值
+func gormSetting(tag, key string) string {
+	for _, s := range strings.Split(tag, ";") {
+		if k, v, ok := strings.Cut(s, ":"); ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
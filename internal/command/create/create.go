@@ -1,17 +1,16 @@
 package create
 
 import (
-	"os"
-	"path"
+	"bytes"
 	"path/filepath"
+	"slices"
 	"strings"
-	"text/template"
 
 	"github.com/spf13/cobra"
 	"github.com/spruce1698/kun/internal/command/create/kernel"
 	"github.com/spruce1698/kun/pkg/fmt"
 	"github.com/spruce1698/kun/pkg/helper"
-	"github.com/spruce1698/kun/tpl"
+	"golang.org/x/tools/imports"
 )
 
 const (
@@ -26,64 +25,99 @@ const (
 var (
 	tplPath string
 
+	showDiff bool
+
+	with []string
+	vars []string
+
 	CmdCreate = &cobra.Command{
 		Use:     "create [type] [name]",
 		Short:   "Create a new ctrl/svc/cs/rt/db/cache",
-		Example: "kun create ctrl user",
+		Example: "kun create ctrl user\n  kun create cs user --dry-run",
 		Args:    cobra.ExactArgs(2),
 		Run:     func(cmd *cobra.Command, args []string) {},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			for _, pair := range vars {
+				k, v, ok := strings.Cut(pair, "=")
+				if !ok || k == "" {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("invalid --set %q, must be key=value", pair)
+				}
+				kernel.Vars[k] = v
+			}
+			if kernel.DryRun {
+				fmt.Warn("dry run, nothing will be written")
+			}
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if kernel.DryRun || showDiff {
+				kernel.PrintChanges()
+			}
+		},
 	}
 
 	CmdCreateController = &cobra.Command{
-		Use:     "ctrl",
-		Short:   "Create a new controller",
-		Example: "kun create ctrl user",
-		Args:    cobra.ExactArgs(1),
-		Run:     runCreate,
+		Use:          "ctrl",
+		Short:        "Create a new controller",
+		Example:      "kun create ctrl user",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runCreate,
+		SilenceUsage: true,
 	}
 
 	CmdCreateService = &cobra.Command{
-		Use:     "svc",
-		Short:   "Create a new service",
-		Example: "kun create svc user",
-		Args:    cobra.ExactArgs(1),
-		Run:     runCreate,
+		Use:          "svc",
+		Short:        "Create a new service",
+		Example:      "kun create svc user",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runCreate,
+		SilenceUsage: true,
 	}
 
 	CmdCreateControllerAndService = &cobra.Command{
-		Use:     "cs",
-		Short:   "Create a new controller & service",
-		Example: "kun create cs user",
-		Args:    cobra.ExactArgs(1),
-		Run:     runCreate,
+		Use:          "cs",
+		Short:        "Create a new controller & service",
+		Example:      "kun create cs user",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runCreate,
+		SilenceUsage: true,
 	}
 
 	CmdCreateRouter = &cobra.Command{
-		Use:     "rt",
-		Short:   "Create a new router",
-		Example: "kun create rt user",
-		Args:    cobra.ExactArgs(1),
-		Run:     runCreate,
+		Use:          "rt",
+		Short:        "Create a new router",
+		Example:      "kun create rt user",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runCreate,
+		SilenceUsage: true,
 	}
 
 	CmdCreateDBRepository = &cobra.Command{
-		Use:     "db",
-		Short:   "Create a new DB repository",
-		Example: "kun create db \"name:pwd@tcp(127.0.0.1:3306)/dbname\" [t1,t2|t1|*]",
-		Args:    cobra.ExactArgs(2),
-		Run:     genDBRepo,
+		Use:          "db",
+		Short:        "Create a new DB repository",
+		Example:      "kun create db \"name:pwd@tcp(127.0.0.1:3306)/dbname\" [t1,t2|t1|*]",
+		Args:         cobra.ExactArgs(2),
+		RunE:         genDBRepo,
+		SilenceUsage: true,
 	}
 
 	CmdCreateCacheRepository = &cobra.Command{
-		Use:     "cache",
-		Short:   "Create a new cache repository",
-		Example: "kun create cache ",
-		Args:    cobra.ExactArgs(1),
-		Run:     runCreate,
+		Use:          "cache",
+		Short:        "Create a new cache repository",
+		Example:      "kun create cache ",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runCreate,
+		SilenceUsage: true,
 	}
 )
 
 func init() {
+	CmdCreate.PersistentFlags().BoolVar(&kernel.DryRun, "dry-run", false, "print the files to create and the diff of modified files without writing")
+	CmdCreate.PersistentFlags().BoolVar(&showDiff, "diff", false, "print the created files and the diff of modified files after writing")
+	CmdCreate.PersistentFlags().StringArrayVar(&vars, "set", nil, "template variable key=value, read by {{var \"key\"}} in templates, repeatable")
+	CmdCreate.PersistentFlags().StringSliceVar(&with, "with", nil, "optional files of the template directory to generate, declared in "+kernel.BundleManifest)
+
 	CmdCreateController.Flags().StringVarP(&tplPath, "tpl-path", "t", tplPath, "template path")
 
 	CmdCreateService.Flags().StringVarP(&tplPath, "tpl-path", "t", tplPath, "template path")
@@ -108,10 +142,17 @@ type Create struct {
 	PackageName        string
 	AddUPPath          string
 	IsFull             bool
+	TplName            string          // 模板名称, 默认为 CreateType
+	With               map[string]bool // --with 选择的模板目录中的可选文件
+	Crud               *Crud           // kun create all 的表结构
 }
 
 func NewCreate() *Create {
-	return &Create{}
+	c := &Create{With: make(map[string]bool)}
+	for _, option := range with {
+		c.With[option] = true
+	}
+	return c
 }
 
 // 文件生成配置
@@ -119,7 +160,8 @@ type genConfig struct {
 	typePath     string
 	defaultPkg   string
 	structSuffix string
-	diBuilder    func(*Create) map[string]string
+	diPath       string // DI 文件所在目录
+	diBuilder    func(c *Create, importPath string) []kernel.DIEntry
 }
 
 // 生成配置映射
@@ -128,16 +170,18 @@ var genConfigs = map[string]genConfig{
 		typePath:     TypeController,
 		defaultPkg:   TypeController,
 		structSuffix: "Ctrl",
-		diBuilder: func(c *Create) map[string]string {
+		diPath:       TypeController,
+		diBuilder: func(c *Create, importPath string) []kernel.DIEntry {
 			packageName := c.PackageName + "."
 			tPrefix := strings.ToUpper(string(c.PackageName[0])) + c.PackageName[1:]
 			if c.PackageName == c.CreateType {
 				packageName = ""
 				tPrefix = ""
+				importPath = ""
 			}
-			return map[string]string{
-				"// ==== Add CtrlCtx before this line, don't edit this line.====": "\t" + tPrefix + c.FileName + "Ctrl *" + packageName + c.FileName + "Ctrl",
-				"// ==== Add Ctrl before this line, don't edit this line.====":    "\twire.Struct(new(" + packageName + c.FileName + "Ctrl), \"*\"),",
+			return []kernel.DIEntry{
+				{Kind: kernel.DIStruct, Target: "ServerCtrlCtx", Code: tPrefix + c.FileName + "Ctrl *" + packageName + c.FileName + "Ctrl", Import: importPath, Marker: "Add CtrlCtx before this line"},
+				{Kind: kernel.DISet, Target: "WireServerSet", Code: "wire.Struct(new(" + packageName + c.FileName + "Ctrl), \"*\")", Import: importPath, Marker: "Add Ctrl before this line"},
 			}
 		},
 	},
@@ -145,24 +189,32 @@ var genConfigs = map[string]genConfig{
 		typePath:     TypeService + "/svc",
 		defaultPkg:   "svc",
 		structSuffix: "Svc",
-		diBuilder: func(c *Create) map[string]string {
-			return map[string]string{
-				"// ==== Add Svc before this line, don't edit this line.====": "\twire.Struct(new(" + c.PackageName + "." + c.FileName + "Ctx), \"*\"),\n    " +
-					c.PackageName + ".New" + c.FileName + "Svc,",
+		diPath:       TypeService,
+		diBuilder: func(c *Create, importPath string) []kernel.DIEntry {
+			var entries []kernel.DIEntry
+			// broker 的 DI 只在使用 kafka/asynq 时存在
+			for _, set := range []string{"WireServerSet", "WireBrokerSet"} {
+				entries = append(entries,
+					kernel.DIEntry{Kind: kernel.DISet, Target: set, Code: "wire.Struct(new(" + c.PackageName + "." + c.FileName + "Ctx), \"*\")", Import: importPath, Marker: "Add Svc before this line", Optional: set != "WireServerSet"},
+					kernel.DIEntry{Kind: kernel.DISet, Target: set, Code: c.PackageName + ".New" + c.FileName + "Svc", Import: importPath, Marker: "Add Svc before this line", Optional: set != "WireServerSet"},
+				)
 			}
+			return entries
 		},
 	},
 	TypeRouter: {
 		typePath:     TypeRouter,
 		defaultPkg:   TypeRouter,
 		structSuffix: "",
-		diBuilder: func(c *Create) map[string]string {
+		diPath:       TypeRouter,
+		diBuilder: func(c *Create, importPath string) []kernel.DIEntry {
 			packageName := c.PackageName + "."
 			if c.PackageName == c.CreateType {
 				packageName = ""
+				importPath = ""
 			}
-			return map[string]string{
-				"// ==== Add Rt before this line, don't edit this line.====": "\t\t" + packageName + c.FileName + ",",
+			return []kernel.DIEntry{
+				{Kind: kernel.DISlice, Target: "WireServerSet", Code: packageName + c.FileName, Import: importPath, Marker: "Add Rt before this line"},
 			}
 		},
 	},
@@ -170,151 +222,227 @@ var genConfigs = map[string]genConfig{
 		typePath:     "repository/cache",
 		defaultPkg:   "cache",
 		structSuffix: "Cache",
-		diBuilder: func(c *Create) map[string]string {
-			return map[string]string{
-				"// ==== Add Repo before this line, don't edit this line.====": "\t" + c.PackageName + ".New" + c.FileName + "Cache,",
+		diPath:       "repository",
+		diBuilder: func(c *Create, importPath string) []kernel.DIEntry {
+			return []kernel.DIEntry{
+				{Kind: kernel.DISet, Target: "WireServerSet", Code: c.PackageName + ".New" + c.FileName + "Cache", Import: importPath, Marker: "Add Repo before this line"},
 			}
 		},
 	},
 }
 
-func runCreate(cmd *cobra.Command, args []string) {
+func runCreate(cmd *cobra.Command, args []string) error {
 	c := NewCreate()
 	c.ProjectName = helper.GetProjectName(".")
 	if c.ProjectName == "" {
-		return
+		return fmt.Errorf("run it in the project root")
 	}
 
 	c.CmdType = cmd.Use
-	c.FilePath, c.FileName = filepath.Split(args[0])
-	c.FileName = strings.ReplaceAll(strings.ToUpper(string(c.FileName[0]))+c.FileName[1:], ".go", "")
-	c.FileNameTitleLower = strings.ToLower(string(c.FileName[0])) + c.FileName[1:]
-	c.FileNameFirstChar = string(c.FileNameTitleLower[0])
+	if err := c.setName(args[0]); err != nil {
+		return fmt.Errorf("create %s error: %w", c.CmdType, err)
+	}
 
 	switch c.CmdType {
 	case "ctrl":
 		c.CreateType = TypeController
-		c.generateFile()
+		return c.generate()
 
 	case "svc":
 		c.CreateType = TypeService
-		c.generateFile()
+		return c.generate()
 
 	case "cs":
 		c.CreateType = TypeController
-		c.generateFile()
+		if err := c.generate(); err != nil {
+			return err
+		}
 
 		c.CreateType = TypeService
-		c.generateFile()
+		return c.generate()
 
 	case "rt":
 		c.CreateType = TypeRouter
-		c.generateFile()
+		return c.generate()
 
 	case "cache":
 		c.CreateType = TypeCache
-		c.generateFile()
+		return c.generate()
 
 	default:
-		fmt.Error("Invalid type: %s", c.CmdType)
+		return fmt.Errorf("invalid type: %s", c.CmdType)
 	}
-
 }
 
-func (c *Create) generateFile() {
-	config, ok := genConfigs[c.CreateType]
-	if !ok {
-		fmt.Error("Invalid type: %s", c.CmdType)
-		return
+// setName 由参数 [dir/]name 设置文件路径和名称, 名称转换为大驼峰, 目录需要是合法的包名
+func (c *Create) setName(arg string) error {
+	dir, name := filepath.Split(arg)
+	name, err := helper.GoName(strings.TrimSuffix(name, ".go"))
+	if err != nil {
+		return err
+	}
+	for _, pkg := range strings.FieldsFunc(dir, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if pkg == "." {
+			continue
+		}
+		if err = helper.PackageName(pkg); err != nil {
+			return err
+		}
 	}
+	c.FilePath = dir
+	c.FileName = name
+	c.FileNameTitleLower = helper.LowerCamelCase(name)
+	c.FileNameFirstChar = string([]rune(c.FileNameTitleLower)[0])
+	return nil
+}
 
-	fileName := strings.ToLower(string(c.FileName[0])) + c.FileName[1:] + ".go"
+// generate 生成文件, 错误中包含组件类型
+func (c *Create) generate() error {
+	if err := c.generateFile(); err != nil {
+		return fmt.Errorf("create %s error: %w", c.CreateType, err)
+	}
+	return nil
+}
 
-	// 构建文件路径
-	filePath := c.FilePath
-	if filePath == "" {
-		filePath = filepath.Join(BasePath, config.typePath)
-	} else {
-		c.AddUPPath = strings.Repeat("../", strings.Count(filePath, "/"))
-		filePath = filepath.Join(BasePath, config.typePath, filePath)
+func (c *Create) generateFile() error {
+	g, err := c.plan()
+	if err != nil || g == nil {
+		return err
 	}
-	filePath = strings.ReplaceAll(strings.ReplaceAll(filePath+"/", "//", "/"), "\\", "/")
+	return g.write()
+}
 
-	absPath, _ := filepath.Abs(filepath.Dir(filepath.Join(filePath, fileName)))
-	absLinuxPath := strings.ReplaceAll(absPath, "\\", "/") + "/"
-	if strings.LastIndex(absLinuxPath, filePath) < 1 {
-		fmt.Error("create %s error: %s", c.CreateType, "not in internal")
-		return
+// generation 渲染好的文件和需要修改的 DI 文件, 由 write 写入
+type generation struct {
+	createType   string
+	diName       string // 注册到 DI 的 New 函数名, 如 NewUserCtrl
+	filePath     string
+	absLinuxPath string
+	files        []kernel.BundleFile
+	diFiles      []*kernel.GoFile
+}
+
+// plan 渲染模板并检查 DI 文件, 不写入; 文件已存在时返回 nil
+func (c *Create) plan() (*generation, error) {
+	config, ok := genConfigs[c.CreateType]
+	if !ok {
+		return nil, fmt.Errorf("invalid type: %s", c.CmdType)
 	}
 
-	// 设置包名
-	_, c.PackageName = filepath.Split(absPath)
-	if c.PackageName == "" {
-		c.PackageName = config.defaultPkg
+	filePath, fileName, err := c.target(config)
+	if err != nil {
+		return nil, err
+	}
+	absPath, _ := filepath.Abs(filePath)
+	g := &generation{
+		createType:   c.CreateType,
+		diName:       "New" + c.FileName + config.structSuffix,
+		filePath:     filePath,
+		absLinuxPath: strings.ReplaceAll(absPath, "\\", "/") + "/",
 	}
 
-	// 根据模板生成文件
-	var t *template.Template
-	var err error
-	if tplPath == "" {
-		t, err = template.ParseFS(tpl.CreateTplFS, fmt.Sprintf("create/%s.tpl", c.CreateType))
-	} else {
-		t, err = template.ParseFiles(path.Join(tplPath, fmt.Sprintf("%s.tpl", c.CreateType)))
+	// 根据模板生成文件, 已存在时不生成
+	if g.files, err = c.render(fileName); err != nil {
+		return nil, err
 	}
-	if err != nil {
-		fmt.Error("create %s error: %s", c.CreateType, err)
-		return
+	for i, f := range g.files {
+		target := filepath.Join(filePath, f.Path)
+		if kernel.Exists(target) {
+			fmt.Warn("warn: file %s%s %s", g.absLinuxPath, filepath.ToSlash(f.Path), "already exists.")
+			return nil, nil
+		}
+		if !strings.HasSuffix(target, ".go") {
+			continue
+		}
+		if g.files[i].Content, err = imports.Process(target, f.Content, nil); err != nil {
+			return nil, fmt.Errorf("format %s: %w", target, err)
+		}
 	}
-	f := createFile(filePath, fileName)
-	if f == nil {
-		fmt.Warn("warn: file %s%s %s", absLinuxPath, fileName, "already exists.")
-		return
+	// 先检查 DI 文件, 无法注册时不生成文件
+	if g.diFiles, err = kernel.PlanDI(filepath.Join(BasePath, config.diPath), config.diBuilder(c, c.importPath(filePath))); err != nil {
+		return nil, fmt.Errorf("generate insert %s to DI file error: %w", g.diName, err)
 	}
-	defer func(f *os.File) {
-		_ = f.Close()
-	}(f)
+	return g, nil
+}
 
-	err = t.Execute(f, c)
-	if err != nil {
-		fmt.Error("create %s error: %s", c.CreateType, err)
-		return
+// write 写入生成的文件并更新 DI 文件
+func (g *generation) write() error {
+	for _, f := range g.files {
+		if err := kernel.WriteFile(filepath.Join(g.filePath, f.Path), f.Content, 0o644); err != nil {
+			return err
+		}
+		fmt.Success("created new %s: %s", g.createType, g.absLinuxPath+filepath.ToSlash(f.Path))
 	}
-	fmt.Success("created new %s: %s", c.CreateType, filepath.Join(absLinuxPath, fileName))
 
 	// 更新DI文件
-	diPath := absLinuxPath
-	if c.CreateType != TypeCache {
-		diPath, _ = filepath.Abs(filepath.Dir(filepath.Join(BasePath, c.CreateType, "X/X")))
-		diPath = strings.ReplaceAll(diPath, "\\", "/")
+	for _, f := range g.diFiles {
+		if err := f.Write(); err != nil {
+			return fmt.Errorf("generate insert %s to DI file error: %w", g.diName, err)
+		}
 	}
+	fmt.Success("generate insert %s to DI file", g.diName)
+	return nil
+}
 
-	contentMap := config.diBuilder(c)
-	if c.PackageName != config.defaultPkg {
-		contentMap["github.com/google/wire"] = "\t\"" + c.ProjectName + "/" + strings.TrimRight(filePath, "/") + "\""
-		contentMap["// ==== Add Rt import  before this line, don't edit this line.===="] = "\t\"" + c.ProjectName + "/" + strings.TrimRight(filePath, "/") + "\""
+// render 渲染模板, 有同名的模板目录时渲染目录中的全部文件, 返回相对于组件目录的文件
+func (c *Create) render(fileName string) ([]kernel.BundleFile, error) {
+	tplName := c.TplName
+	if tplName == "" {
+		tplName = c.CreateType
+	}
+	bundle, err := kernel.FindBundle(tplPath, tplName)
+	if err != nil {
+		return nil, err
+	}
+	if bundle != nil {
+		for option := range c.With {
+			if !slices.Contains(bundle.Options(), option) {
+				fmt.Warn("warn: --with %s is not declared in %s", option, filepath.Join(bundle.Dir, kernel.BundleManifest))
+			}
+		}
+		return bundle.Render(c, c.With)
 	}
 
-	if err = kernel.Wire2DIFile(diPath, contentMap); err != nil {
-		fmt.Error("generate insert New%s%s to DI file error: %s", c.FileName, config.structSuffix, err)
-		return
+	t, err := kernel.ParseTemplate(tplPath, tplName)
+	if err != nil {
+		return nil, err
 	}
-	fmt.Success("generate insert New%s%s to DI file", c.FileName, config.structSuffix)
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, c); err != nil {
+		return nil, err
+	}
+	return []kernel.BundleFile{{Path: fileName, Content: buf.Bytes()}}, nil
 }
 
-func createFile(dirPath string, filename string) *os.File {
-	filePath := filepath.Join(dirPath, filename)
-	err := os.MkdirAll(dirPath, os.ModePerm)
-	if err != nil {
-		fmt.Error("failed to create dir %s: %v", dirPath, err)
+// target 文件所在目录和文件名, 并设置包名
+func (c *Create) target(config genConfig) (filePath, fileName string, err error) {
+	fileName = c.FileNameTitleLower + ".go"
+
+	// 构建文件路径
+	filePath = c.FilePath
+	if filePath == "" {
+		filePath = filepath.Join(BasePath, config.typePath)
+	} else {
+		c.AddUPPath = strings.Repeat("../", strings.Count(filePath, "/"))
+		filePath = filepath.Join(BasePath, config.typePath, filePath)
 	}
-	stat, _ := os.Stat(filePath)
-	if stat != nil {
-		return nil
+	filePath = strings.ReplaceAll(strings.ReplaceAll(filePath+"/", "//", "/"), "\\", "/")
+
+	absPath, _ := filepath.Abs(filepath.Dir(filepath.Join(filePath, fileName)))
+	absLinuxPath := strings.ReplaceAll(absPath, "\\", "/") + "/"
+	if strings.LastIndex(absLinuxPath, filePath) < 1 {
+		return "", "", fmt.Errorf("not in internal")
 	}
-	file, err := os.Create(filePath)
-	if err != nil {
-		fmt.Error("failed to create file %s: %v", filePath, err)
+
+	// 设置包名
+	_, c.PackageName = filepath.Split(absPath)
+	if c.PackageName == "" {
+		c.PackageName = config.defaultPkg
 	}
+	return filePath, fileName, nil
+}
 
-	return file
+// importPath 目录的包路径
+func (c *Create) importPath(filePath string) string {
+	return c.ProjectName + "/" + strings.TrimRight(filePath, "/")
 }
@@ -60,7 +60,7 @@ func connectDB(t DBType, dsn string) (*gorm.DB, error) {
 	}
 }
 
-func genDBRepo(cmd *cobra.Command, args []string) {
+func genDBRepo(cmd *cobra.Command, args []string) error {
 	cmdConf := &CmdParams{
 		DSN:     args[0],
 		DBType:  "mysql",
@@ -73,21 +73,22 @@ func genDBRepo(cmd *cobra.Command, args []string) {
 			cmdConf.Tables = strings.Split(args[1], ",")
 		}
 	}
+	return generateDBRepo(cmdConf)
+}
 
+// generateDBRepo 连接数据库并生成表对应的 repository
+func generateDBRepo(cmdConf *CmdParams) error {
 	outPath, err := filepath.Abs(cmdConf.OutPath)
 	if err != nil {
-		fmt.Error("outPath is invalid: %s", err)
-		return
+		return fmt.Errorf("outPath is invalid: %w", err)
 	}
 
 	gormDb, err := connectDB(DBType(cmdConf.DBType), cmdConf.DSN)
 	if err != nil {
-		fmt.Error("connect db server fail: %s", err)
-		return
+		return fmt.Errorf("connect db server fail: %w", err)
 	}
 	if gormDb == nil {
-		fmt.Error("gorm db is nil")
-		return
+		return fmt.Errorf("gorm db is nil")
 	}
 	// 自定义命名策略
 	gormDb.Config.NamingStrategy = schema.NamingStrategy{
@@ -99,6 +100,7 @@ func genDBRepo(cmd *cobra.Command, args []string) {
 		DbConn:            gormDb,
 		OutPath:           outPath, // 指定输出目录
 		PackageName:       "db",    // Repo代码的包名称,同数据库类型相同。
+		TplPath:           tplPath, // 自定义模板目录
 		FieldCoverable:    false,   // 当字段具有默认值时生成指针，以解决无法分配零值的问题
 		FieldNullable:     true,    // 当字段可为空时生成指针
 		FieldWithIndexTag: true,    // 生成字段包含 索引 标记
@@ -111,8 +113,7 @@ func genDBRepo(cmd *cobra.Command, args []string) {
 		// Execute tasks for all tables in the database
 		tablesList, err = gormDb.Migrator().GetTables()
 		if err != nil {
-			fmt.Error("GORM migrator get all tables fail: %s", err)
-			return
+			return fmt.Errorf("GORM migrator get all tables fail: %w", err)
 		}
 	} else {
 		tablesList = cmdConf.Tables
@@ -122,5 +123,5 @@ func genDBRepo(cmd *cobra.Command, args []string) {
 	}
 
 	g.Execute()
-
+	return nil
 }
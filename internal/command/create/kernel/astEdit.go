@@ -0,0 +1,241 @@
+package kernel
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// GoFile 解析后的 Go 文件, 修改时按 AST 的位置插入或删除源码, 原有代码和注释保持不变
+type GoFile struct {
+	Path string
+	Fset *token.FileSet
+	File *ast.File
+	Src  []byte
+
+	edits         []edit
+	imports       []string
+	removeImports []string
+}
+
+// edit 将 [start, end) 替换为 text, 插入时 start == end
+type edit struct {
+	start, end int
+	text       string
+}
+
+// ParseGoFile 解析文件
+func ParseGoFile(path string) (*GoFile, error) {
+	src, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", path, err)
+	}
+	return &GoFile{Path: path, Fset: fset, File: file, Src: src}, nil
+}
+
+// ParseGoDir 解析目录下除 _test.go 外的文件, recursive 为 true 时包括子目录
+func ParseGoDir(dir string, recursive bool) ([]*GoFile, error) {
+	var files []*GoFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") || isDeleted(path) {
+			return nil
+		}
+		f, err := ParseGoFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// DryRun 时尚未写入的新文件
+	for _, path := range createdIn(dir, recursive) {
+		if strings.HasSuffix(path, "_test.go") || !strings.HasSuffix(path, ".go") {
+			continue
+		}
+		if slices.ContainsFunc(files, func(f *GoFile) bool { return absPath(f.Path) == path }) {
+			continue
+		}
+		f, err := ParseGoFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// Offset 位置在文件中的偏移
+func (g *GoFile) Offset(pos token.Pos) int {
+	return g.Fset.Position(pos).Offset
+}
+
+// Text 节点的源码
+func (g *GoFile) Text(node ast.Node) string {
+	return string(g.Src[g.Offset(node.Pos()):g.Offset(node.End())])
+}
+
+// Insert 在 pos 处插入源码
+func (g *GoFile) Insert(pos token.Pos, text string) {
+	offset := g.Offset(pos)
+	g.edits = append(g.edits, edit{start: offset, end: offset, text: text})
+}
+
+// Delete 删除 [from, to) 的源码
+func (g *GoFile) Delete(from, to token.Pos) {
+	g.edits = append(g.edits, edit{start: g.Offset(from), end: g.Offset(to)})
+}
+
+// Replace 将 [from, to) 的源码替换为 text
+func (g *GoFile) Replace(from, to token.Pos, text string) {
+	g.edits = append(g.edits, edit{start: g.Offset(from), end: g.Offset(to), text: text})
+}
+
+// Pos 偏移在文件中的位置
+func (g *GoFile) Pos(offset int) token.Pos {
+	return g.Fset.File(g.File.Pos()).Pos(offset)
+}
+
+// DeleteLines 删除节点所在的整行, 节点独占这些行时返回 true
+func (g *GoFile) DeleteLines(node ast.Node) bool {
+	start, end := g.Offset(node.Pos()), g.Offset(node.End())
+	lineStart := bytes.LastIndexByte(g.Src[:start], '\n') + 1
+	if len(bytes.TrimSpace(g.Src[lineStart:start])) > 0 {
+		return false
+	}
+	lineEnd := bytes.IndexByte(g.Src[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(g.Src) - end
+	}
+	// 行尾只允许逗号和注释
+	rest := strings.TrimSpace(string(g.Src[end : end+lineEnd]))
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, ","))
+	if rest != "" && !strings.HasPrefix(rest, "//") {
+		return false
+	}
+	g.edits = append(g.edits, edit{start: lineStart, end: min(end+lineEnd+1, len(g.Src))})
+	return true
+}
+
+// AddImport 输出时添加 import, 已存在时忽略
+func (g *GoFile) AddImport(path string) {
+	if !slices.Contains(g.imports, path) {
+		g.imports = append(g.imports, path)
+	}
+}
+
+// RemoveImport 输出时删除不再使用的 import
+func (g *GoFile) RemoveImport(path string) {
+	if !slices.Contains(g.removeImports, path) {
+		g.removeImports = append(g.removeImports, path)
+	}
+}
+
+// Changed 是否有修改
+func (g *GoFile) Changed() bool {
+	return len(g.edits) > 0
+}
+
+// Output 修改源码并处理 import 后 gofmt 的结果
+func (g *GoFile) Output() ([]byte, error) {
+	src := g.Src
+	if len(g.edits) > 0 {
+		edits := slices.Clone(g.edits)
+		// 同一位置按修改的顺序输出
+		slices.SortStableFunc(edits, func(a, b edit) int { return a.start - b.start })
+		var buf bytes.Buffer
+		start := 0
+		for _, e := range edits {
+			if e.start < start {
+				return nil, fmt.Errorf("overlapping edits in %s", g.Path)
+			}
+			buf.Write(src[start:e.start])
+			buf.WriteString(e.text)
+			start = e.end
+		}
+		buf.Write(src[start:])
+		src = buf.Bytes()
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, g.Path, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse modified %s error: %w", g.Path, err)
+	}
+	for _, path := range g.imports {
+		astutil.AddImport(fset, file, path)
+	}
+	for _, path := range g.removeImports {
+		if astutil.UsesImport(file, path) {
+			continue
+		}
+		for _, spec := range file.Imports {
+			if p, _ := strconv.Unquote(spec.Path.Value); p == path && spec.Name != nil {
+				astutil.DeleteNamedImport(fset, file, spec.Name.Name, path)
+			}
+		}
+		astutil.DeleteImport(fset, file, path)
+	}
+	var out bytes.Buffer
+	if err = format.Node(&out, fset, file); err != nil {
+		return nil, fmt.Errorf("format %s error: %w", g.Path, err)
+	}
+	return out.Bytes(), nil
+}
+
+// Write 写回文件
+func (g *GoFile) Write() error {
+	content, err := g.Output()
+	if err != nil {
+		return err
+	}
+	perm := fs.FileMode(0o644)
+	if stat, err := os.Stat(g.Path); err == nil {
+		perm = stat.Mode().Perm()
+	}
+	return WriteFile(g.Path, content, perm)
+}
+
+// ImportPath 文件中包名对应的 import 路径, 未指定别名时按路径最后一段匹配
+func (g *GoFile) ImportPath(name string) string {
+	for _, spec := range g.File.Imports {
+		path := strings.Trim(spec.Path.Value, `"`)
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return path
+			}
+			continue
+		}
+		if filepath.Base(path) == name {
+			return path
+		}
+	}
+	return ""
+}
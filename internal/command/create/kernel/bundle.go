@@ -0,0 +1,153 @@
+package kernel
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gopkg.in/yaml.v3"
+)
+
+// BundleManifest 模板目录中声明可选文件的清单
+const BundleManifest = "kun.bundle.yaml"
+
+// Bundle 模板目录, 目录中的每个 .tpl 文件生成一个文件, 相对路径去掉 .tpl 后也按模板渲染
+type Bundle struct {
+	Dir   string
+	Rules []BundleRule `yaml:"rules"`
+}
+
+// BundleRule 可选文件: --with When 时删除 Exclude, 否则删除 Include
+type BundleRule struct {
+	When    string   `yaml:"when"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// BundleFile 渲染后的文件, Path 相对于组件所在目录
+type BundleFile struct {
+	Path    string
+	Content []byte
+}
+
+// FindBundle 按 TplDirs 的顺序在每一级先查找名为 name 的模板目录, 再查找 name.tpl,
+// 先找到 name.tpl 或都不存在时返回 nil, 由 ParseTemplate 使用单个模板
+func FindBundle(tplPath, name string) (*Bundle, error) {
+	for _, dir := range TplDirs(tplPath) {
+		if stat, err := os.Stat(filepath.Join(dir, name)); err == nil && stat.IsDir() {
+			return loadBundle(filepath.Join(dir, name))
+		}
+		// 同一级的 name.tpl 优先于更低优先级的模板目录
+		if _, err := os.Stat(filepath.Join(dir, name+".tpl")); err == nil {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// loadBundle 读取模板目录中的清单, 没有清单时没有可选文件
+func loadBundle(dir string) (*Bundle, error) {
+	b := &Bundle{Dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, BundleManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", filepath.Join(dir, BundleManifest), err)
+	}
+	for _, r := range b.Rules {
+		if r.When == "" {
+			return nil, fmt.Errorf("%s: rule needs when", filepath.Join(dir, BundleManifest))
+		}
+	}
+	return b, nil
+}
+
+// Options 清单中声明的 --with 选项
+func (b *Bundle) Options() []string {
+	var options []string
+	for _, r := range b.Rules {
+		if !slices.Contains(options, r.When) {
+			options = append(options, r.When)
+		}
+	}
+	return options
+}
+
+// Render 渲染目录中的模板, 跳过未选择的可选文件和路径渲染为空的文件
+func (b *Bundle) Render(data any, with map[string]bool) ([]BundleFile, error) {
+	var skip []string
+	for _, r := range b.Rules {
+		if with[r.When] {
+			skip = append(skip, r.Exclude...)
+		} else {
+			skip = append(skip, r.Include...)
+		}
+	}
+
+	var files []BundleFile
+	err := filepath.WalkDir(b.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".tpl") {
+			return err
+		}
+		rel, err := filepath.Rel(b.Dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if slices.ContainsFunc(skip, func(pattern string) bool { return helper.MatchPath(pattern, rel) }) {
+			return nil
+		}
+
+		out, err := execute(rel, strings.TrimSuffix(rel, ".tpl"), data)
+		if err != nil {
+			return fmt.Errorf("render path of %s error: %w", path, err)
+		}
+		out = bytes.TrimSpace(out)
+		if len(out) == 0 {
+			return nil
+		}
+		// 不允许生成到组件目录之外
+		if !filepath.IsLocal(string(out)) {
+			return fmt.Errorf("%s: illegal output path %q", path, out)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if content, err = execute(rel, string(content), data); err != nil {
+			return fmt.Errorf("render %s error: %w", path, err)
+		}
+		files = append(files, BundleFile{Path: filepath.Clean(string(out)), Content: content})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file is generated by the template directory %s", b.Dir)
+	}
+	return files, nil
+}
+
+func execute(name, text string, data any) ([]byte, error) {
+	t, err := NewTemplate(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,163 @@
+package kernel
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindBundle(t *testing.T) {
+	// 相对于临时目录: 工作目录为 work, XDG_CONFIG_HOME 为临时目录, -t 为 flag
+	const (
+		flag    = "flag/"
+		project = "work/" + ProjectTplDir + "/"
+		user    = "kun/create/"
+	)
+	tests := []struct {
+		name    string
+		files   []string
+		tplPath bool
+		want    string // 模板目录, 为空时使用单个模板
+	}{
+		{name: "no template", files: []string{project + "service.tpl"}},
+		{name: "project bundle", files: []string{project + "controller/a.go.tpl"}, want: project + "controller"},
+		{name: "user bundle", files: []string{user + "controller/a.go.tpl"}, want: user + "controller"},
+		{
+			name:  "bundle before template in the same dir",
+			files: []string{project + "controller.tpl", project + "controller/a.go.tpl"},
+			want:  project + "controller",
+		},
+		{
+			name:  "project template before user bundle",
+			files: []string{project + "controller.tpl", user + "controller/a.go.tpl"},
+		},
+		{
+			name:    "flag template before project bundle",
+			files:   []string{flag + "controller.tpl", project + "controller/a.go.tpl"},
+			tplPath: true,
+		},
+		{
+			name:    "flag bundle before project template",
+			files:   []string{flag + "controller/a.go.tpl", project + "controller.tpl"},
+			tplPath: true,
+			want:    flag + "controller",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			files := map[string]string{"work/go.mod": "module work\n"}
+			for _, f := range tt.files {
+				files[f] = ""
+			}
+			writeFiles(t, root, files)
+			t.Chdir(filepath.Join(root, "work"))
+			t.Setenv("XDG_CONFIG_HOME", root)
+			tplPath := ""
+			if tt.tplPath {
+				tplPath = filepath.Join(root, flag)
+			}
+
+			b, err := FindBundle(tplPath, "controller")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if b != nil {
+				got, _ = filepath.Abs(b.Dir)
+			}
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(root, tt.want)
+			}
+			if got != want {
+				t.Errorf("FindBundle() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestBundleRender(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"{{.Name}}.go.tpl":                       "package {{.Pkg}}\n",
+		"{{.Name}}_req.go.tpl":                   "package {{.Pkg}} // req\n",
+		"{{.Name}}_test.go.tpl":                  "package {{.Pkg}} // test\n",
+		"mock/{{.Name}}.go.tpl":                  "package mock\n",
+		"{{if not .Skip}}optional.go{{end}}.tpl": "",
+		"README.md":                              "not a template",
+	})
+	b := &Bundle{Dir: dir, Rules: []BundleRule{
+		{When: "test", Include: []string{"*_test.go.tpl"}},
+		{When: "mock", Include: []string{"mock/**"}, Exclude: []string{"*_req.go.tpl"}},
+	}}
+	data := map[string]any{"Name": "user", "Pkg": "controller", "Skip": true}
+
+	tests := []struct {
+		with map[string]bool
+		want []string
+	}{
+		{want: []string{"user.go", "user_req.go"}},
+		{with: map[string]bool{"test": true}, want: []string{"user.go", "user_req.go", "user_test.go"}},
+		{with: map[string]bool{"mock": true}, want: []string{filepath.Join("mock", "user.go"), "user.go"}},
+	}
+	for _, tt := range tests {
+		files, err := b.Render(data, tt.with)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range files {
+			got = append(got, f.Path)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Render(with %v) = %q, want %q", tt.with, got, tt.want)
+		}
+	}
+
+	files, err := b.Render(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Path == "user.go" && string(f.Content) != "package controller\n" {
+			t.Errorf("user.go = %q", f.Content)
+		}
+	}
+}
+
+func TestBundleRenderError(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "escape", files: map[string]string{`{{"..\x2fx.go"}}.tpl`: ""}},
+		{name: "absolute", files: map[string]string{`{{"\x2ftmp\x2fx.go"}}.tpl`: ""}},
+		{name: "bad template", files: map[string]string{"a.go.tpl": "{{.Missing"}},
+		{name: "no files", files: map[string]string{"{{if false}}a{{end}}.tpl": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+			if _, err := (&Bundle{Dir: dir}).Render(map[string]any{}, nil); err == nil {
+				t.Error("Render() error = nil, want error")
+			}
+		})
+	}
+}
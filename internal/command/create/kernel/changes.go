@@ -0,0 +1,157 @@
+package kernel
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+// DryRun 为 true 时不写入文件, 只记录变更, 之后的读取返回记录的内容
+var DryRun bool
+
+// change 生成、修改或删除的文件
+type change struct {
+	path    string // 绝对路径
+	old     []byte
+	new     []byte
+	created bool
+	deleted bool
+}
+
+var changes []*change
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+func findChange(path string) *change {
+	path = absPath(path)
+	for _, c := range changes {
+		if c.path == path {
+			return c
+		}
+	}
+	return nil
+}
+
+// ReadFile 读取文件, 已记录变更时返回变更后的内容
+func ReadFile(path string) ([]byte, error) {
+	if c := findChange(path); c != nil {
+		if c.deleted {
+			return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+		}
+		return c.new, nil
+	}
+	return os.ReadFile(path)
+}
+
+// Exists 文件是否存在, 包括 DryRun 时记录的新文件
+func Exists(path string) bool {
+	if c := findChange(path); c != nil {
+		return !c.deleted
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// WriteFile 写入文件并记录变更, 目录不存在时创建, DryRun 时只记录
+func WriteFile(path string, content []byte, perm fs.FileMode) error {
+	c := findChange(path)
+	if c == nil {
+		old, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		c = &change{path: absPath(path), old: old, created: os.IsNotExist(err)}
+		changes = append(changes, c)
+	}
+	c.new, c.deleted = content, false
+	if DryRun {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, perm)
+}
+
+// RemoveFile 删除文件并记录变更, DryRun 时只记录
+func RemoveFile(path string) error {
+	c := findChange(path)
+	if c == nil {
+		old, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		c = &change{path: absPath(path), old: old}
+		changes = append(changes, c)
+	}
+	c.new, c.deleted = nil, true
+	if DryRun {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// isDeleted 文件是否已删除
+func isDeleted(path string) bool {
+	c := findChange(path)
+	return c != nil && c.deleted
+}
+
+// createdIn DryRun 时在 dir 下新建的文件, recursive 为 true 时包括子目录
+func createdIn(dir string, recursive bool) []string {
+	dir = absPath(dir)
+	var paths []string
+	for _, c := range changes {
+		if !c.created || c.deleted {
+			continue
+		}
+		if filepath.Dir(c.path) == dir || recursive && strings.HasPrefix(c.path, dir+string(filepath.Separator)) {
+			paths = append(paths, c.path)
+		}
+	}
+	return paths
+}
+
+// PrintChanges 输出新建的文件和修改的文件的 unified diff
+func PrintChanges() {
+	if DryRun {
+		fmt.Warn("dry run, nothing was written")
+	}
+	if len(changes) == 0 {
+		fmt.Print("no file changed")
+		return
+	}
+	wd, _ := os.Getwd()
+	for _, c := range changes {
+		name := c.path
+		if rel, err := filepath.Rel(wd, c.path); err == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+		if c.deleted {
+			if !c.created {
+				fmt.Success("delete %s", name)
+			}
+			continue
+		}
+		if c.created {
+			fmt.Success("create %s", name)
+			continue
+		}
+		diff := helper.UnifiedDiff("a/"+name, "b/"+name, c.old, c.new)
+		if diff == "" {
+			continue
+		}
+		fmt.Success("modify %s", name)
+		fmt.Fprintln(os.Stdout, strings.TrimSuffix(diff, "\n"))
+	}
+}
@@ -0,0 +1,183 @@
+package kernel
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"golang.org/x/tools/go/packages"
+)
+
+// LoadPackages 加载 dir 所在模块的全部包及测试, 包含类型信息, 有编译错误时返回错误
+// 依赖也从源码检查类型, 不依赖 go 版本对应的导出数据格式
+func LoadPackages(dir string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:   dir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			errs = append(errs, e.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("the project does not compile, fix it first:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return pkgs, nil
+}
+
+// ObjectKey 对象声明的位置, 同一个包的测试变体及由导出数据加载的对象也相同
+func ObjectKey(pkg *packages.Package, obj types.Object) string {
+	pos := pkg.Fset.Position(obj.Pos())
+	return fmt.Sprintf("%s:%d:%d", absPath(pos.Filename), pos.Line, pos.Column)
+}
+
+// PlanRename 将 rename 返回新名称的对象的声明和引用重命名, 返回修改的文件, 不写入
+// rename 按对象的声明位置只调用一次, 返回空字符串时不修改
+func PlanRename(pkgs []*packages.Package, rename func(pkg *packages.Package, obj types.Object) string) ([]*GoFile, error) {
+	names := make(map[string]string)
+	files := make(map[string]*GoFile)
+	var changed []*GoFile
+	done := make(map[string]bool)
+
+	for _, pkg := range pkgs {
+		visit := func(id *ast.Ident, obj types.Object, def bool) error {
+			if obj == nil || obj.Pkg() == nil || !obj.Pos().IsValid() {
+				return nil
+			}
+			key := ObjectKey(pkg, obj)
+			name, ok := names[key]
+			if !ok {
+				name = rename(pkg, obj)
+				names[key] = name
+			}
+			if name == "" || name == id.Name {
+				return nil
+			}
+			pos := pkg.Fset.Position(id.Pos())
+			path := absPath(pos.Filename)
+			// 测试变体中相同的文件只修改一次
+			at := fmt.Sprintf("%s:%d", path, pos.Offset)
+			if done[at] {
+				return nil
+			}
+			done[at] = true
+			f := files[path]
+			if f == nil {
+				var err error
+				if f, err = ParseGoFile(pos.Filename); err != nil {
+					return err
+				}
+				files[path] = f
+				changed = append(changed, f)
+			}
+			start := f.Pos(pos.Offset)
+			f.Replace(start, start+token.Pos(len(id.Name)), name)
+			if def {
+				renameDoc(f, start, id.Name, name)
+			}
+			return nil
+		}
+		for id, obj := range pkg.TypesInfo.Defs {
+			if err := visit(id, obj, true); err != nil {
+				return nil, err
+			}
+		}
+		for id, obj := range pkg.TypesInfo.Uses {
+			if err := visit(id, obj, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	slices.SortFunc(changed, func(a, b *GoFile) int { return strings.Compare(a.Path, b.Path) })
+	for _, f := range changed {
+		if _, err := f.Output(); err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
+
+// renameDoc 声明的注释以旧名称开头时替换为新名称, 如 // UserCtrl ..., 跳过 //go:generate 等指令
+func renameDoc(f *GoFile, pos token.Pos, oldName, newName string) {
+	doc := declDoc(f.File, pos)
+	if doc == nil {
+		return
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, "//") && len(c.Text) > 2 && !unicode.IsSpace(rune(c.Text[2])) {
+			continue
+		}
+		i := len(c.Text) - len(strings.TrimLeftFunc(c.Text[2:], unicode.IsSpace))
+		text := c.Text[i:]
+		if !strings.HasPrefix(text, oldName) {
+			return
+		}
+		// 旧名称是其他标识符的前缀, 如 UserCtrlFactory
+		if r, _ := utf8.DecodeRuneInString(text[len(oldName):]); r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return
+		}
+		start := c.Pos() + token.Pos(i)
+		f.Replace(start, start+token.Pos(len(oldName)), newName)
+		return
+	}
+}
+
+// declDoc 名称在 pos 的声明(函数、类型、变量、常量、字段)的注释
+func declDoc(file *ast.File, pos token.Pos) *ast.CommentGroup {
+	var doc *ast.CommentGroup
+	ast.Inspect(file, func(n ast.Node) bool {
+		if doc != nil || n == nil || pos < n.Pos() || pos >= n.End() {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Name.Pos() == pos {
+				doc = n.Doc
+				return false
+			}
+		case *ast.GenDecl:
+			// 只有一个声明且没有括号时注释在 GenDecl 上
+			for _, spec := range n.Specs {
+				var names []*ast.Ident
+				var specDoc *ast.CommentGroup
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names, specDoc = []*ast.Ident{spec.Name}, spec.Doc
+				case *ast.ValueSpec:
+					names, specDoc = spec.Names, spec.Doc
+				}
+				for _, name := range names {
+					if name.Pos() != pos {
+						continue
+					}
+					doc = specDoc
+					if doc == nil && !n.Lparen.IsValid() {
+						doc = n.Doc
+					}
+					return false
+				}
+			}
+		case *ast.Field:
+			for _, name := range n.Names {
+				if name.Pos() == pos {
+					doc = n.Doc
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return doc
+}
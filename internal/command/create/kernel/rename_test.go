@@ -0,0 +1,93 @@
+package kernel
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenameDoc(t *testing.T) {
+	const src = `package controller
+
+// UserCtrl handles users.
+//
+//go:generate mockgen -source=user.go
+type UserCtrl struct {
+	// UserSvc the service of UserCtrl.
+	UserSvc any
+}
+
+// NewUserCtrl creates a UserCtrl.
+//
+//go:generate echo
+func NewUserCtrl() any { return nil }
+
+var (
+	// UserCtrlFactory is not renamed.
+	UserCtrlFactory = NewUserCtrl
+	UserCtrlDefault = NewUserCtrl()
+)
+
+// UserCtrlCount the count.
+var UserCtrlCount int
+`
+	const want = `package controller
+
+// MemberCtrl handles users.
+//
+//go:generate mockgen -source=user.go
+type MemberCtrl struct {
+	// MemberSvc the service of UserCtrl.
+	MemberSvc any
+}
+
+// NewMemberCtrl creates a UserCtrl.
+//
+//go:generate echo
+func NewMemberCtrl() any { return nil }
+
+var (
+	// UserCtrlFactory is not renamed.
+	UserCtrlFactory   = NewUserCtrl
+	MemberCtrlDefault = NewUserCtrl()
+)
+
+// MemberCtrlCount the count.
+var MemberCtrlCount int
+`
+	path := filepath.Join(t.TempDir(), "user.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseGoFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renames := map[string]string{
+		"UserCtrl":        "MemberCtrl",
+		"UserSvc":         "MemberSvc",
+		"NewUserCtrl":     "NewMemberCtrl",
+		"UserCtrlDefault": "MemberCtrlDefault",
+		"UserCtrlCount":   "MemberCtrlCount",
+	}
+	// 只重命名声明, 引用由 PlanRename 按类型信息修改
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Obj == nil || id.Obj.Pos() != id.Pos() {
+			return true
+		}
+		if name, ok := renames[id.Name]; ok {
+			f.Replace(id.Pos(), id.End(), name)
+			renameDoc(f, id.Pos(), id.Name, name)
+		}
+		return true
+	})
+	got, err := f.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("renameDoc() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -8,11 +8,9 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
-	"text/template"
-	"unicode"
 
 	"github.com/spruce1698/kun/pkg/fmt"
-	"github.com/spruce1698/kun/tpl"
+	"github.com/spruce1698/kun/pkg/helper"
 	"golang.org/x/tools/imports"
 	"gorm.io/gorm"
 )
@@ -23,6 +21,7 @@ type SQLConfig struct {
 
 	OutPath     string // query code path
 	PackageName string // generated repository code's package name
+	TplPath     string // template path, searched before .kun/templates, the user config dir and the embedded templates
 
 	// generate repository global configuration
 	FieldNullable     bool // generate pointer when field is nullable
@@ -167,7 +166,7 @@ func (g *Generator) getStructMeta(tableName, structName string) (*StructMeta, er
 		return nil, fmt.Errorf("repo name %q is invalid: %w", structName, err)
 	}
 
-	fileName := string(unicode.ToLower(rune(structName[0]))) + structName[1:]
+	fileName := helper.LowerCamelCase(structName)
 
 	columns, err := g.getTableColumns(tableName)
 	if err != nil || len(columns) == 0 {
@@ -260,10 +259,6 @@ func (g *Generator) generateRepoFile() error {
 		return err
 	}
 
-	if err = os.MkdirAll(repoOutPath, os.ModePerm); err != nil {
-		return fmt.Errorf("create repository pkg path(%s) fail: %w", repoOutPath, err)
-	}
-
 	for _, data := range g.repos {
 		if data == nil {
 			continue
@@ -277,8 +272,7 @@ func (g *Generator) generateRepoFile() error {
 		fmt.Success("generate repository file(table <%s> -> {%s.%s}): %s", data.TableName, data.PackageName, data.StructName, repoFile)
 
 		repoFile = filepath.Join(repoOutPath, data.FileName+".go")
-		_, StatErr := os.Stat(repoFile)
-		if StatErr != nil {
+		if !Exists(repoFile) {
 			err = g.output("dbCustom", data, repoFile)
 			if err != nil {
 				return err
@@ -286,10 +280,9 @@ func (g *Generator) generateRepoFile() error {
 			fmt.Success("generate repository file(table <%s> -> {%s.%s}): %s", data.TableName, data.PackageName, data.StructName, repoFile)
 		}
 
-		contentMap := map[string]string{
-			"// ==== Add Repo before this line, don't edit this line.====": "    " + data.PackageName + ".New" + data.StructName + "Db,",
-		}
-		err = Wire2DIFile(repoOutPath, contentMap)
+		_, err = Wire2DI(filepath.Join(repoOutPath, ".."), []DIEntry{
+			{Kind: DISet, Target: "WireServerSet", Code: data.PackageName + ".New" + data.StructName + "Db", Marker: "Add Repo before this line"},
+		})
 		if err != nil {
 			fmt.Error("generate db repository insert New%sDb to DI file error: %s", data.StructName, err)
 			continue
@@ -314,7 +307,7 @@ func (g *Generator) getRepoOutputPath() (outPath string, err error) {
 
 // Format and output
 func (g *Generator) output(tmpl string, data interface{}, fileName string) error {
-	t, err := template.ParseFS(tpl.CreateTplFS, fmt.Sprintf("create/%s.tpl", tmpl))
+	t, err := ParseTemplate(g.Conf.TplPath, tmpl)
 	if err != nil {
 		return err
 	}
@@ -327,7 +320,7 @@ func (g *Generator) output(tmpl string, data interface{}, fileName string) error
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(fileName, result, 0640)
+	return WriteFile(fileName, result, 0640)
 }
 
 func (g *Generator) checkStructName(name string) error {
@@ -0,0 +1,119 @@
+package kernel
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/jinzhu/inflection"
+	"github.com/spruce1698/kun/config"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"github.com/spruce1698/kun/tpl"
+	"golang.org/x/mod/modfile"
+)
+
+// ProjectTplDir 项目中覆盖 kun create 内置模板的目录
+const ProjectTplDir = ".kun/templates"
+
+// Vars --set 传入的模板变量, 模板中通过 var 函数读取
+var Vars = make(map[string]string)
+
+// Project 模板中通过 project 函数读取的项目信息
+type Project struct {
+	ModulePath string    // go module 路径
+	GoVersion  string    // go.mod 中的 go 版本
+	KunVersion string    // kun 的版本
+	Time       time.Time // 生成时间
+}
+
+// project 读取当前目录的 go.mod, 每次运行只读取一次
+var project = sync.OnceValues(func() (*Project, error) {
+	data, err := os.ReadFile("go.mod")
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return nil, err
+	}
+	p := &Project{KunVersion: config.Version, Time: time.Now()}
+	if f.Module != nil {
+		p.ModulePath = f.Module.Mod.Path
+	}
+	if f.Go != nil {
+		p.GoVersion = f.Go.Version
+	}
+	return p, nil
+})
+
+// funcs 模板中可用的函数
+var funcs = template.FuncMap{
+	"camel":      helper.CamelCase,
+	"lowerCamel": helper.LowerCamelCase,
+	"snake":      helper.SnakeCase,
+	"kebab":      helper.KebabCase,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"plural":     inflection.Plural,
+	"singular":   inflection.Singular,
+	"project":    project,
+	// var 读取 --set 传入的变量, 未设置时使用默认值, 没有默认值时报错
+	"var": func(key string, def ...string) (string, error) {
+		if v, ok := Vars[key]; ok {
+			return v, nil
+		}
+		if len(def) > 0 {
+			return def[0], nil
+		}
+		return "", fmt.Errorf("variable %s is not set, use --set %s=value", key, key)
+	},
+}
+
+// NewTemplate 创建带有模板函数的模板
+func NewTemplate(name string) *template.Template {
+	return template.New(name).Funcs(funcs)
+}
+
+// TplDirs 按优先级查找模板的目录: tplPath, 项目的 .kun/templates, 用户配置目录下的 create
+func TplDirs(tplPath string) []string {
+	var dirs []string
+	if tplPath != "" {
+		dirs = append(dirs, tplPath)
+	}
+	dirs = append(dirs, ProjectTplDir)
+	if dir, err := helper.ConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "create"))
+	}
+	return dirs
+}
+
+// ParseTemplate 按 TplDirs 的顺序逐个文件查找 name.tpl, 都不存在时使用内置的模板
+func ParseTemplate(tplPath, name string) (*template.Template, error) {
+	file := name + ".tpl"
+	for _, dir := range TplDirs(tplPath) {
+		path := filepath.Join(dir, file)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		t, err := NewTemplate(file).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse template %s error: %w", path, err)
+		}
+		return t, nil
+	}
+
+	content, err := fs.ReadFile(tpl.CreateTplFS, "create/"+file)
+	if err != nil {
+		return nil, fmt.Errorf("template %s does not exist", file)
+	}
+	return NewTemplate(file).Parse(string(content))
+}
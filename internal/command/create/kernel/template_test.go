@@ -0,0 +1,74 @@
+package kernel
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spruce1698/kun/config"
+)
+
+func render(t *testing.T, text string) (string, error) {
+	t.Helper()
+	tpl, err := NewTemplate("test").Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = tpl.Execute(&buf, map[string]string{"Name": "UserProfile"})
+	return buf.String(), err
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	t.Cleanup(func() { Vars = make(map[string]string) })
+	Vars = map[string]string{"author": "spruce", "empty": ""}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{{camel "api_key"}}`, "APIKey"},
+		{`{{lowerCamel .Name}}`, "userProfile"},
+		{`{{snake .Name}}`, "user_profile"},
+		{`{{kebab .Name}}`, "user-profile"},
+		{`{{lower .Name}} {{upper .Name}}`, "userprofile USERPROFILE"},
+		{`{{plural "user"}} {{plural "category"}} {{plural "person"}}`, "users categories people"},
+		{`{{singular "users"}} {{singular "categories"}}`, "user category"},
+		{`{{snake .Name | plural}}`, "user_profiles"},
+		{`{{var "author"}}`, "spruce"},
+		{`{{var "empty" "default"}}`, ""},
+		{`{{var "team" "core"}}`, "core"},
+	}
+	for _, tt := range tests {
+		got, err := render(t, tt.text)
+		if err != nil {
+			t.Errorf("%s: %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := render(t, `{{var "team"}}`); err == nil || !strings.Contains(err.Error(), "--set team=value") {
+		t.Errorf(`var "team" error = %v, want not set`, err)
+	}
+}
+
+func TestTemplateProject(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/a/shop\n\ngo 1.24.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	got, err := render(t, `{{project.ModulePath}} {{project.GoVersion}} {{project.KunVersion}} {{project.Time.IsZero}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "github.com/a/shop 1.24.0 " + config.Version + " false"; got != want {
+		t.Errorf("project = %q, want %q", got, want)
+	}
+}
@@ -1,77 +1,371 @@
 package kernel
 
 import (
-	"bytes"
-	"os"
-	"path/filepath"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/spruce1698/kun/pkg/fmt"
 )
 
-// 写入都DI文件,
-func Wire2DIFile(filePath string, contentMap map[string]string) error {
-	// 构建目录路径
-	dirName := filepath.Join(filePath, "../")
-	// 读取目录内容
-	fileInfos, err := os.ReadDir(dirName)
+// DI 文件中注册的位置
+const (
+	DISet    = "set"    // var Target = wire.NewSet(...) 的参数
+	DIStruct = "struct" // type Target struct{...} 的字段
+	DISlice  = "slice"  // func Target() 中返回的切片的元素
+)
+
+// DIEntry 需要注册到 DI 文件的内容
+type DIEntry struct {
+	Kind     string
+	Target   string
+	Code     string // 表达式或字段, 如 svc.NewUserSvc, UserCtrl *UserCtrl
+	Import   string // Code 中使用的包, 文件中已有别名时使用已有的别名
+	Marker   string // 有包含 Marker 的注释时插入到注释之前
+	Optional bool   // 目标不存在时忽略
+}
+
+// Wire2DI 将 entries 注册到 dir 目录下的 DI 文件并写回, 已存在的跳过, 返回修改的文件
+func Wire2DI(dir string, entries []DIEntry) ([]string, error) {
+	files, err := PlanDI(dir, entries)
 	if err != nil {
-		return fmt.Errorf("failed to read the directory: %w", err)
+		return nil, err
 	}
-	DIFileFound := false
-	// 遍历文件
-	for _, fileInfo := range fileInfos {
-		if fileInfo.IsDir() {
-			continue
+	var changed []string
+	for _, f := range files {
+		if err = f.Write(); err != nil {
+			return nil, err
 		}
-		DIFileFound = true
-		DIPath := filepath.Join(dirName, fileInfo.Name())
-		for markerLine, appendContent := range contentMap {
-			if err = wireProcess(DIPath, markerLine, appendContent); err != nil {
-				return fmt.Errorf("processing files %s Failed: %w", DIPath, err)
+		changed = append(changed, f.Path)
+	}
+	return changed, nil
+}
+
+// PlanDI 解析 dir 目录下的 DI 文件并插入 entries, 返回需要修改的文件, 不写入
+func PlanDI(dir string, entries []DIEntry) ([]*GoFile, error) {
+	files, err := ParseGoDir(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the DI file does not exist in %s", dir)
+	}
+	for _, entry := range entries {
+		found := false
+		for _, f := range files {
+			ok, err := f.addDI(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Path, err)
 			}
+			found = found || ok
+		}
+		if !found && !entry.Optional {
+			return nil, fmt.Errorf("%s %s not found in %s", entry.Kind, entry.Target, dir)
 		}
 	}
-	// 没有找到DI文件
-	if !DIFileFound {
-		return fmt.Errorf("the DI file does not exist")
+
+	var changed []*GoFile
+	for _, f := range files {
+		if !f.Changed() {
+			continue
+		}
+		if _, err = f.Output(); err != nil {
+			return nil, err
+		}
+		changed = append(changed, f)
 	}
-	return nil
+	return changed, nil
 }
 
-// 写入内容到文件
-func wireProcess(filePath, markerLine, appendContent string) error {
-	// 读取文件内容
-	content, err := os.ReadFile(filePath)
+// PlanUnwire 解析 dir 目录下的 DI 文件并删除 entries 及不再使用的 import, 返回需要修改的文件和删除的数量, 不写入
+func PlanUnwire(dir string, entries []DIEntry) ([]*GoFile, int, error) {
+	files, err := ParseGoDir(dir, false)
 	if err != nil {
-		return err
+		return nil, 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		for _, f := range files {
+			n, err := f.removeDI(entry)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", f.Path, err)
+			}
+			removed += n
+		}
+	}
+
+	var changed []*GoFile
+	for _, f := range files {
+		if !f.Changed() {
+			continue
+		}
+		if _, err = f.Output(); err != nil {
+			return nil, 0, err
+		}
+		changed = append(changed, f)
+	}
+	return changed, removed, nil
+}
+
+// entryCode 文件中已有别名时替换 Code 中的包名
+func (g *GoFile) entryCode(entry DIEntry) string {
+	code := entry.Code
+	if entry.Import != "" {
+		name := path.Base(entry.Import)
+		if alias := g.importName(entry.Import); alias != "" && alias != name {
+			code = strings.ReplaceAll(code, name+".", alias+".")
+		}
+	}
+	return code
+}
+
+// addDI 在文件中查找 entry 的目标并插入, 返回是否找到目标
+func (g *GoFile) addDI(entry DIEntry) (bool, error) {
+	code := g.entryCode(entry)
+
+	switch entry.Kind {
+	case DISet:
+		call := g.findSet(entry.Target)
+		if call == nil {
+			return false, nil
+		}
+		expr, err := parser.ParseExpr(code)
+		if err != nil {
+			return true, fmt.Errorf("invalid DI code %s: %w", code, err)
+		}
+		if slices.ContainsFunc(call.Args, func(arg ast.Expr) bool { return types.ExprString(arg) == types.ExprString(expr) }) {
+			return true, nil
+		}
+		g.insertElem(call.Args, call.Lparen, call.Rparen, code, entry.Marker)
+
+	case DIStruct:
+		st := g.findStruct(entry.Target)
+		if st == nil {
+			return false, nil
+		}
+		name, _, _ := strings.Cut(strings.TrimSpace(code), " ")
+		for _, f := range st.Fields.List {
+			if slices.ContainsFunc(f.Names, func(n *ast.Ident) bool { return n.Name == name }) {
+				return true, nil
+			}
+		}
+		pos := st.Fields.Closing
+		if marker := g.marker(st.Fields.Opening, st.Fields.Closing, entry.Marker); marker.IsValid() {
+			pos = marker
+		}
+		g.Insert(pos, code+"\n")
+
+	case DISlice:
+		lit := g.findSlice(entry.Target)
+		if lit == nil {
+			return false, nil
+		}
+		expr, err := parser.ParseExpr(code)
+		if err != nil {
+			return true, fmt.Errorf("invalid DI code %s: %w", code, err)
+		}
+		if slices.ContainsFunc(lit.Elts, func(e ast.Expr) bool { return types.ExprString(e) == types.ExprString(expr) }) {
+			return true, nil
+		}
+		g.insertElem(lit.Elts, lit.Lbrace, lit.Rbrace, code, entry.Marker)
+
+	default:
+		return false, fmt.Errorf("invalid DI kind %s", entry.Kind)
+	}
+
+	if entry.Import != "" && g.importName(entry.Import) == "" {
+		g.AddImport(entry.Import)
+	}
+	return true, nil
+}
+
+// removeDI 在文件中删除 entry, 返回删除的数量
+func (g *GoFile) removeDI(entry DIEntry) (int, error) {
+	code := g.entryCode(entry)
+	removed := 0
+	switch entry.Kind {
+	case DISet, DISlice:
+		var elems []ast.Expr
+		if entry.Kind == DISet {
+			if call := g.findSet(entry.Target); call != nil {
+				elems = call.Args
+			}
+		} else if lit := g.findSlice(entry.Target); lit != nil {
+			elems = lit.Elts
+		}
+		expr, err := parser.ParseExpr(code)
+		if err != nil {
+			return 0, fmt.Errorf("invalid DI code %s: %w", code, err)
+		}
+		for i, e := range elems {
+			if types.ExprString(e) == types.ExprString(expr) {
+				g.deleteElem(elems, i)
+				removed++
+			}
+		}
+
+	case DIStruct:
+		st := g.findStruct(entry.Target)
+		if st == nil {
+			return 0, nil
+		}
+		// 字段名不同时按类型匹配
+		name, typ, _ := strings.Cut(strings.TrimSpace(code), " ")
+		expr, err := parser.ParseExpr(strings.TrimSpace(typ))
+		if err != nil {
+			return 0, fmt.Errorf("invalid DI code %s: %w", code, err)
+		}
+		for _, f := range st.Fields.List {
+			if len(f.Names) == 1 && (f.Names[0].Name == name || types.ExprString(f.Type) == types.ExprString(expr)) {
+				if !g.DeleteLines(f) {
+					g.Delete(f.Pos(), f.End())
+				}
+				removed++
+			}
+		}
+
+	default:
+		return 0, fmt.Errorf("invalid DI kind %s", entry.Kind)
 	}
 
-	// 检查是否已包含要插入的内容
-	for _, v := range strings.Split(appendContent, "\n") {
-		if bytes.Contains(content, []byte(strings.Trim(strings.Trim(v, " "), "\t"))) {
-			return nil
+	if removed > 0 && entry.Import != "" {
+		g.RemoveImport(entry.Import)
+	}
+	return removed, nil
+}
+
+// deleteElem 删除参数或元素列表中的一项
+func (g *GoFile) deleteElem(elems []ast.Expr, i int) {
+	switch {
+	case g.DeleteLines(elems[i]):
+	case i+1 < len(elems):
+		g.Delete(elems[i].Pos(), elems[i+1].Pos())
+	case i > 0:
+		g.Delete(elems[i-1].End(), elems[i].End())
+	default:
+		g.Delete(elems[i].Pos(), elems[i].End())
+	}
+}
+
+// insertElem 在参数或元素列表中追加一项
+func (g *GoFile) insertElem(elems []ast.Expr, open, close token.Pos, code, marker string) {
+	if pos := g.marker(open, close, marker); pos.IsValid() {
+		g.Insert(pos, code+",\n")
+		return
+	}
+	if len(elems) == 0 {
+		g.Insert(close, code)
+		return
+	}
+	last := elems[len(elems)-1]
+	if g.Fset.Position(close).Line > g.Fset.Position(last.End()).Line {
+		// 多行时最后一项后已有逗号
+		g.Insert(last.End(), ",\n"+code)
+		return
+	}
+	g.Insert(last.End(), ", "+code)
+}
+
+// marker 在 open 和 close 之间包含 marker 的注释所在行的行首
+func (g *GoFile) marker(open, close token.Pos, marker string) token.Pos {
+	if marker == "" {
+		return token.NoPos
+	}
+	for _, group := range g.File.Comments {
+		if group.Pos() < open || group.End() > close {
+			continue
+		}
+		for _, c := range group.List {
+			if strings.Contains(c.Text, marker) {
+				return g.Fset.File(c.Pos()).LineStart(g.Fset.Position(c.Pos()).Line)
+			}
 		}
 	}
+	return token.NoPos
+}
 
-	// 处理文件内容
-	lines := strings.Split(string(content), "\n")
-	var newContent []string
+// findSet var name = wire.NewSet(...)
+func (g *GoFile) findSet(name string) *ast.CallExpr {
+	for _, decl := range g.File.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, n := range vs.Names {
+				if n.Name != name || i >= len(vs.Values) {
+					continue
+				}
+				if call, ok := vs.Values[i].(*ast.CallExpr); ok {
+					if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "NewSet" {
+						return call
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
 
-	markerFound := false
-	for _, line := range lines {
-		if strings.Contains(line, markerLine) {
-			newContent = append(newContent, appendContent)
-			markerFound = true
+// findStruct type name struct{...}
+func (g *GoFile) findStruct(name string) *ast.StructType {
+	for _, decl := range g.File.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					return st
+				}
+			}
 		}
-		newContent = append(newContent, line)
 	}
+	return nil
+}
 
-	// 如果没有找到标记行，不进行修改
-	if !markerFound {
-		return nil
+// findSlice func name() 中第一个返回的切片字面量
+func (g *GoFile) findSlice(name string) *ast.CompositeLit {
+	for _, decl := range g.File.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv != nil || fd.Name.Name != name || fd.Body == nil {
+			continue
+		}
+		var lit *ast.CompositeLit
+		ast.Inspect(fd.Body, func(n ast.Node) bool {
+			if ret, ok := n.(*ast.ReturnStmt); ok && lit == nil {
+				for _, r := range ret.Results {
+					if cl, ok := r.(*ast.CompositeLit); ok {
+						if _, ok = cl.Type.(*ast.ArrayType); ok {
+							lit = cl
+							break
+						}
+					}
+				}
+			}
+			return lit == nil
+		})
+		return lit
 	}
+	return nil
+}
 
-	// 写回文件
-	return os.WriteFile(filePath, []byte(strings.Join(newContent, "\n")), 0644)
+// importName 文件中 import path 使用的包名, 未 import 时为空
+func (g *GoFile) importName(importPath string) string {
+	for _, spec := range g.File.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == importPath {
+			if spec.Name != nil {
+				return spec.Name.Name
+			}
+			return path.Base(importPath)
+		}
+	}
+	return ""
 }
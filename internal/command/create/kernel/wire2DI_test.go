@@ -0,0 +1,264 @@
+package kernel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const diSrc = `package controller
+
+import (
+	"github.com/google/wire"
+
+	"app/internal/controller/admin"
+)
+
+type ServerCtrlCtx struct {
+	AdminUserCtrl *admin.UserCtrl
+	// Add CtrlCtx before this line
+}
+
+var WireServerSet = wire.NewSet(
+	NewServerCtrlCtx,
+	wire.Struct(new(admin.UserCtrl), "*"),
+	// Add Ctrl before this line
+)
+
+func Routers() []any {
+	return []any{Home}
+}
+`
+
+func TestPlanDI(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []DIEntry
+		want    string // 为空时没有修改
+		wantErr bool
+	}{
+		{
+			name: "struct, set and import",
+			entries: []DIEntry{
+				{Kind: DIStruct, Target: "ServerCtrlCtx", Code: "OrderCtrl *order.Ctrl", Import: "app/internal/controller/order", Marker: "Add CtrlCtx before this line"},
+				{Kind: DISet, Target: "WireServerSet", Code: `wire.Struct(new(order.Ctrl), "*")`, Import: "app/internal/controller/order", Marker: "Add Ctrl before this line"},
+			},
+			want: `package controller
+
+import (
+	"github.com/google/wire"
+
+	"app/internal/controller/admin"
+	"app/internal/controller/order"
+)
+
+type ServerCtrlCtx struct {
+	AdminUserCtrl *admin.UserCtrl
+	OrderCtrl     *order.Ctrl
+	// Add CtrlCtx before this line
+}
+
+var WireServerSet = wire.NewSet(
+	NewServerCtrlCtx,
+	wire.Struct(new(admin.UserCtrl), "*"),
+	wire.Struct(new(order.Ctrl), "*"),
+	// Add Ctrl before this line
+)
+
+func Routers() []any {
+	return []any{Home}
+}
+`,
+		},
+		{
+			name:    "slice",
+			entries: []DIEntry{{Kind: DISlice, Target: "Routers", Code: "User"}},
+			want: `package controller
+
+import (
+	"github.com/google/wire"
+
+	"app/internal/controller/admin"
+)
+
+type ServerCtrlCtx struct {
+	AdminUserCtrl *admin.UserCtrl
+	// Add CtrlCtx before this line
+}
+
+var WireServerSet = wire.NewSet(
+	NewServerCtrlCtx,
+	wire.Struct(new(admin.UserCtrl), "*"),
+	// Add Ctrl before this line
+)
+
+func Routers() []any {
+	return []any{Home, User}
+}
+`,
+		},
+		{
+			// AdminUserCtrl 已注册时 UserCtrl 不能被当作已存在
+			name: "prefix collision",
+			entries: []DIEntry{
+				{Kind: DIStruct, Target: "ServerCtrlCtx", Code: "UserCtrl *UserCtrl", Marker: "Add CtrlCtx before this line"},
+				{Kind: DISet, Target: "WireServerSet", Code: `wire.Struct(new(UserCtrl), "*")`, Marker: "Add Ctrl before this line"},
+			},
+			want: `package controller
+
+import (
+	"github.com/google/wire"
+
+	"app/internal/controller/admin"
+)
+
+type ServerCtrlCtx struct {
+	AdminUserCtrl *admin.UserCtrl
+	UserCtrl      *UserCtrl
+	// Add CtrlCtx before this line
+}
+
+var WireServerSet = wire.NewSet(
+	NewServerCtrlCtx,
+	wire.Struct(new(admin.UserCtrl), "*"),
+	wire.Struct(new(UserCtrl), "*"),
+	// Add Ctrl before this line
+)
+
+func Routers() []any {
+	return []any{Home}
+}
+`,
+		},
+		{
+			name: "already registered",
+			entries: []DIEntry{
+				{Kind: DIStruct, Target: "ServerCtrlCtx", Code: "AdminUserCtrl *admin.UserCtrl", Import: "app/internal/controller/admin"},
+				{Kind: DISet, Target: "WireServerSet", Code: `wire.Struct(new(admin.UserCtrl), "*")`, Import: "app/internal/controller/admin"},
+				{Kind: DISlice, Target: "Routers", Code: "Home"},
+			},
+		},
+		{
+			name:    "missing target",
+			entries: []DIEntry{{Kind: DISet, Target: "WireBrokerSet", Code: "NewBroker"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing optional target",
+			entries: []DIEntry{{Kind: DISet, Target: "WireBrokerSet", Code: "NewBroker", Optional: true}},
+		},
+		{
+			name:    "invalid code",
+			entries: []DIEntry{{Kind: DISet, Target: "WireServerSet", Code: "new("}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"serverDI.go": diSrc})
+
+			files, err := PlanDI(dir, tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlanDI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == "" {
+				if len(files) != 0 {
+					t.Errorf("PlanDI() changed %d files, want none", len(files))
+				}
+				return
+			}
+			if len(files) != 1 {
+				t.Fatalf("PlanDI() changed %d files, want 1", len(files))
+			}
+			got, err := files[0].Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("PlanDI() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWire2DI(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"serverDI.go": diSrc})
+	entries := []DIEntry{{Kind: DISlice, Target: "Routers", Code: "User"}}
+
+	changed, err := Wire2DI(dir, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 {
+		t.Fatalf("Wire2DI() changed %q, want serverDI.go", changed)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "serverDI.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第二次注册时已存在, 不修改
+	if changed, err = Wire2DI(dir, entries); err != nil || len(changed) != 0 {
+		t.Errorf("Wire2DI() again = %q, %v, want no change", changed, err)
+	}
+	again, _ := os.ReadFile(filepath.Join(dir, "serverDI.go"))
+	if string(again) != string(content) {
+		t.Errorf("Wire2DI() again modified the file:\n%s", again)
+	}
+
+	// DI 目标不存在时不写入任何文件
+	if _, err = Wire2DI(dir, []DIEntry{
+		{Kind: DISlice, Target: "Routers", Code: "Order"},
+		{Kind: DISet, Target: "WireBrokerSet", Code: "NewBroker"},
+	}); err == nil {
+		t.Fatal("Wire2DI() error = nil, want error")
+	}
+	again, _ = os.ReadFile(filepath.Join(dir, "serverDI.go"))
+	if string(again) != string(content) {
+		t.Errorf("Wire2DI() wrote the file on error:\n%s", again)
+	}
+}
+
+func TestPlanUnwire(t *testing.T) {
+	const want = `package controller
+
+import (
+	"github.com/google/wire"
+)
+
+type ServerCtrlCtx struct {
+	// Add CtrlCtx before this line
+}
+
+var WireServerSet = wire.NewSet(
+	NewServerCtrlCtx,
+	// Add Ctrl before this line
+)
+
+func Routers() []any {
+	return []any{Home}
+}
+`
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"serverDI.go": diSrc})
+
+	files, removed, err := PlanUnwire(dir, []DIEntry{
+		{Kind: DIStruct, Target: "ServerCtrlCtx", Code: "AdminUserCtrl *admin.UserCtrl", Import: "app/internal/controller/admin"},
+		{Kind: DISet, Target: "WireServerSet", Code: `wire.Struct(new(admin.UserCtrl), "*")`, Import: "app/internal/controller/admin"},
+		{Kind: DISlice, Target: "Routers", Code: "Missing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 || len(files) != 1 {
+		t.Fatalf("PlanUnwire() removed %d in %d files, want 2 in 1", removed, len(files))
+	}
+	got, err := files[0].Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("PlanUnwire() =\n%s\nwant\n%s", got, want)
+	}
+}
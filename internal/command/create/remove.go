@@ -0,0 +1,478 @@
+package create
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/internal/command/create/kernel"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	force bool
+
+	CmdRemove = &cobra.Command{
+		Use:     "remove [type] [name]",
+		Short:   "Remove a ctrl/svc/cs/rt/db/cache and its DI entries",
+		Example: "kun remove cs user\n  kun remove db user_info --dry-run",
+		Args:    cobra.ExactArgs(2),
+		Run:     func(cmd *cobra.Command, args []string) {},
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if kernel.DryRun {
+				fmt.Warn("dry run, nothing will be written")
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if kernel.DryRun || showDiff {
+				kernel.PrintChanges()
+			}
+		},
+	}
+
+	CmdRemoveController = &cobra.Command{
+		Use:          "ctrl",
+		Short:        "Remove a controller",
+		Example:      "kun remove ctrl user",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runRemove,
+	}
+
+	CmdRemoveService = &cobra.Command{
+		Use:          "svc",
+		Short:        "Remove a service",
+		Example:      "kun remove svc user",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runRemove,
+	}
+
+	CmdRemoveControllerAndService = &cobra.Command{
+		Use:          "cs",
+		Short:        "Remove a controller & service",
+		Example:      "kun remove cs user",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runRemove,
+	}
+
+	CmdRemoveRouter = &cobra.Command{
+		Use:          "rt",
+		Short:        "Remove a router",
+		Example:      "kun remove rt user",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runRemove,
+	}
+
+	CmdRemoveDBRepository = &cobra.Command{
+		Use:          "db",
+		Short:        "Remove a DB repository",
+		Example:      "kun remove db user_info",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runRemove,
+	}
+
+	CmdRemoveCacheRepository = &cobra.Command{
+		Use:          "cache",
+		Short:        "Remove a cache repository",
+		Example:      "kun remove cache user",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runRemove,
+	}
+)
+
+func init() {
+	CmdRemove.PersistentFlags().BoolVar(&kernel.DryRun, "dry-run", false, "print the files to delete and the diff of modified files without writing")
+	CmdRemove.PersistentFlags().BoolVar(&showDiff, "diff", false, "print the deleted files and the diff of modified files after writing")
+	CmdRemove.PersistentFlags().BoolVarP(&force, "force", "f", false, "remove even if it is still referenced")
+}
+
+// mockgen 生成的文件
+var mockDestination = regexp.MustCompile(`^//go:generate\s+mockgen\s.*-destination=(\S+)`)
+
+// remover 删除的文件及其导出的标识符
+type remover struct {
+	projectName string
+	files       []string                  // 删除的文件
+	dirs        map[string][]string       // 目录 -> 删除的标识符, 同一个包内的引用
+	imports     map[string][]string       // import path -> 删除的标识符
+	fields      []string                  // ServerCtrlCtx 中删除的字段
+	diFiles     map[string]*kernel.GoFile // 绝对路径 -> 修改的 DI 文件
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	c := NewCreate()
+	c.ProjectName = helper.GetProjectName(".")
+	if c.ProjectName == "" {
+		return fmt.Errorf("run it in the project root")
+	}
+	c.CmdType = cmd.Use
+	if c.CmdType != "db" {
+		if err := c.setName(args[0]); err != nil {
+			return err
+		}
+	}
+
+	r := &remover{
+		projectName: c.ProjectName,
+		dirs:        make(map[string][]string),
+		imports:     make(map[string][]string),
+		diFiles:     make(map[string]*kernel.GoFile),
+	}
+	var names []string
+	switch c.CmdType {
+	case "ctrl":
+		names = []string{TypeController}
+	case "svc":
+		names = []string{TypeService}
+	case "cs":
+		names = []string{TypeController, TypeService}
+	case "rt":
+		names = []string{TypeRouter}
+	case "cache":
+		names = []string{TypeCache}
+	case "db":
+		if err := r.addDBRepo(args[0]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid type: %s", c.CmdType)
+	}
+	for _, name := range names {
+		c.CreateType = name
+		if err := r.add(c); err != nil {
+			return err
+		}
+	}
+
+	if !force {
+		refs, err := r.references()
+		if err != nil {
+			return err
+		}
+		if len(refs) > 0 {
+			return fmt.Errorf("still referenced, remove the references first or use --force:\n  %s", strings.Join(refs, "\n  "))
+		}
+	}
+
+	for _, file := range r.files {
+		if err := kernel.RemoveFile(file); err != nil {
+			return err
+		}
+	}
+	for _, f := range r.sortedDIFiles() {
+		if err := f.Write(); err != nil {
+			return err
+		}
+	}
+	// dry run 时由 PrintChanges 输出将要删除和修改的文件
+	if kernel.DryRun {
+		return nil
+	}
+	for _, file := range r.files {
+		fmt.Success("removed %s", filepath.ToSlash(file))
+	}
+	for _, f := range r.sortedDIFiles() {
+		fmt.Success("removed DI entries from %s", filepath.ToSlash(f.Path))
+	}
+	fmt.Success("run `kun wire all` to regenerate wire_gen.go")
+	return nil
+}
+
+// add 添加 controller/service/router/cache 的文件和 DI
+func (r *remover) add(c *Create) error {
+	config, ok := genConfigs[c.CreateType]
+	if !ok {
+		return fmt.Errorf("invalid type: %s", c.CreateType)
+	}
+	filePath, fileName, err := c.target(config)
+	if err != nil {
+		return err
+	}
+	importPath := c.importPath(filePath)
+	if err = r.addFile(filepath.Join(filePath, fileName), importPath); err != nil {
+		return err
+	}
+
+	diPath := filepath.Join(BasePath, config.diPath)
+	if c.CreateType == TypeController {
+		// 与 DI 文件同一个包时不需要 import
+		if c.PackageName == c.CreateType {
+			importPath = ""
+		}
+		if err = r.addCtxFields(diPath, importPath, c.FileName+config.structSuffix); err != nil {
+			return err
+		}
+	}
+	return r.unwire(diPath, config.diBuilder(c, c.importPath(filePath)), c.FileName+config.structSuffix)
+}
+
+// addCtxFields 记录 ServerCtrlCtx 中类型为 importPath 包中 name 的字段, importPath 为空时为同一个包
+func (r *remover) addCtxFields(dir, importPath, name string) error {
+	files, err := kernel.ParseGoDir(dir, false)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		alias := ""
+		for _, spec := range f.File.Imports {
+			if p, _ := strconv.Unquote(spec.Path.Value); importPath != "" && p == importPath {
+				alias = filepath.Base(p)
+				if spec.Name != nil {
+					alias = spec.Name.Name
+				}
+			}
+		}
+		if importPath != "" && alias == "" {
+			continue
+		}
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			ts, ok := n.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != "ServerCtrlCtx" {
+				return true
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+			for _, field := range st.Fields.List {
+				if len(field.Names) != 1 || typeName(field.Type) != name {
+					continue
+				}
+				pkg := ""
+				if sel, ok := unstar(field.Type).(*ast.SelectorExpr); ok {
+					if x, ok := sel.X.(*ast.Ident); ok {
+						pkg = x.Name
+					}
+				}
+				if pkg == alias {
+					r.fields = append(r.fields, field.Names[0].Name)
+				}
+			}
+			return false
+		})
+	}
+	return nil
+}
+
+// addDBRepo 添加 kun create db 生成的 repository 文件和 DI
+func (r *remover) addDBRepo(name string) error {
+	name = strings.TrimSuffix(name, "Db")
+	if strings.ContainsAny(name, `/\.`) {
+		return fmt.Errorf("invalid table name %s", name)
+	}
+	naming := schema.NamingStrategy{SingularTable: true}
+	structName := strings.ReplaceAll(naming.SchemaName(name), "ID", "Id")
+	importPath := r.projectName + "/" + filepath.ToSlash(filepath.Clean(DefaultOutPath))
+	// _gen.go 可能已被删除, 只要求存在其中一个
+	found := false
+	for _, file := range []string{helper.LowerCamelCase(structName) + ".go", helper.LowerCamelCase(structName) + "_gen.go"} {
+		file = filepath.Join(DefaultOutPath, file)
+		if !kernel.Exists(file) {
+			continue
+		}
+		if err := r.addFile(file, importPath); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("%s does not exist", filepath.ToSlash(filepath.Join(DefaultOutPath, helper.LowerCamelCase(structName)+".go")))
+	}
+	entries := []kernel.DIEntry{
+		{Kind: kernel.DISet, Target: "WireServerSet", Code: "db.New" + structName + "Db", Import: importPath},
+	}
+	return r.unwire(filepath.Dir(DefaultOutPath), entries, structName+"Db")
+}
+
+// addFile 添加删除的文件, 记录其导出的标识符和 mockgen 生成的文件
+func (r *remover) addFile(file, importPath string) error {
+	if !kernel.Exists(file) {
+		return fmt.Errorf("%s does not exist", filepath.ToSlash(file))
+	}
+	f, err := kernel.ParseGoFile(file)
+	if err != nil {
+		return err
+	}
+	r.files = append(r.files, file)
+
+	var names []string
+	for _, decl := range f.File.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.IsExported() {
+				names = append(names, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						names = append(names, s.Name.Name)
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.IsExported() {
+							names = append(names, n.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	dir := filepath.Dir(f.Path)
+	r.dirs[dir] = append(r.dirs[dir], names...)
+	r.imports[importPath] = append(r.imports[importPath], names...)
+
+	for _, group := range f.File.Comments {
+		for _, comment := range group.List {
+			m := mockDestination.FindStringSubmatch(comment.Text)
+			if m == nil {
+				continue
+			}
+			mock := filepath.Join(dir, m[1])
+			if kernel.Exists(mock) && !slices.Contains(r.files, mock) {
+				r.files = append(r.files, mock)
+			}
+		}
+	}
+	return nil
+}
+
+// unwire 删除 dir 目录下 DI 文件中的 entries
+func (r *remover) unwire(dir string, entries []kernel.DIEntry, name string) error {
+	files, removed, err := kernel.PlanUnwire(dir, entries)
+	if err != nil {
+		return fmt.Errorf("remove %s from DI file error: %w", name, err)
+	}
+	if removed == 0 {
+		fmt.Warn("warn: %s is not registered in the DI file of %s", name, filepath.ToSlash(dir))
+	}
+	for _, f := range files {
+		abs, _ := filepath.Abs(f.Path)
+		if r.diFiles[abs] != nil {
+			return fmt.Errorf("remove %s from DI file error: %s is modified twice", name, f.Path)
+		}
+		r.diFiles[abs] = f
+	}
+	return nil
+}
+
+func (r *remover) sortedDIFiles() []*kernel.GoFile {
+	var files []*kernel.GoFile
+	for _, f := range r.diFiles {
+		files = append(files, f)
+	}
+	slices.SortFunc(files, func(a, b *kernel.GoFile) int { return strings.Compare(a.Path, b.Path) })
+	return files
+}
+
+// references 项目中其他文件对删除的标识符的引用, DI 文件按删除后的内容检查
+func (r *remover) references() ([]string, error) {
+	ctrlImport := r.projectName + "/" + BasePath + "/" + TypeController
+	removed := make(map[string]bool)
+	for _, file := range r.files {
+		abs, _ := filepath.Abs(file)
+		removed[abs] = true
+	}
+
+	var refs []string
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// wire_gen.go 由 kun wire all 重新生成
+		if !strings.HasSuffix(path, ".go") || d.Name() == "wire_gen.go" {
+			return nil
+		}
+		abs, _ := filepath.Abs(path)
+		if removed[abs] {
+			return nil
+		}
+
+		var src []byte
+		if f := r.diFiles[abs]; f != nil {
+			src, err = f.Output()
+		} else {
+			src, err = kernel.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+
+		local := r.dirs[filepath.Dir(abs)]
+		aliases := make(map[string][]string)
+		usesCtrl := filepath.ToSlash(filepath.Dir(path)) == BasePath+"/"+TypeController
+		for _, spec := range file.Imports {
+			p, _ := strconv.Unquote(spec.Path.Value)
+			name := filepath.Base(p)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if names := r.imports[p]; len(names) > 0 {
+				aliases[name] = names
+			}
+			usesCtrl = usesCtrl || p == ctrlImport
+		}
+		if len(local) == 0 && len(aliases) == 0 && (!usesCtrl || len(r.fields) == 0) {
+			return nil
+		}
+
+		ref := func(n ast.Node, name string) {
+			refs = append(refs, fmt.Sprintf("%s:%d %s", filepath.ToSlash(path), fset.Position(n.Pos()).Line, name))
+		}
+		var visit func(n ast.Node) bool
+		visit = func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Field:
+				// 字段名不是引用
+				if n.Type != nil {
+					ast.Inspect(n.Type, visit)
+				}
+				return false
+			case *ast.SelectorExpr:
+				if id, ok := n.X.(*ast.Ident); ok && slices.Contains(aliases[id.Name], n.Sel.Name) {
+					ref(n, id.Name+"."+n.Sel.Name)
+					return false
+				}
+				if usesCtrl && slices.Contains(r.fields, n.Sel.Name) {
+					ref(n, n.Sel.Name)
+				}
+				ast.Inspect(n.X, visit)
+				return false
+			case *ast.Ident:
+				if slices.Contains(local, n.Name) {
+					ref(n, n.Name)
+				}
+			}
+			return true
+		}
+		ast.Inspect(file, visit)
+		return nil
+	})
+	return refs, err
+}
@@ -0,0 +1,422 @@
+package create
+
+import (
+	"go/types"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/internal/command/create/kernel"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"golang.org/x/tools/go/packages"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	CmdRename = &cobra.Command{
+		Use:     "rename [type] [old] [new]",
+		Short:   "Rename a ctrl/svc/cs/rt/db/cache with its types, files and DI entries",
+		Example: "kun rename cs user member\n  kun rename db user_info member_info --dry-run",
+		Args:    cobra.ExactArgs(3),
+		Run:     func(cmd *cobra.Command, args []string) {},
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if kernel.DryRun {
+				fmt.Warn("dry run, nothing will be written")
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if kernel.DryRun || showDiff {
+				kernel.PrintChanges()
+			}
+		},
+	}
+
+	CmdRenameController = &cobra.Command{
+		Use:          "ctrl",
+		Short:        "Rename a controller",
+		Example:      "kun rename ctrl user member",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runRename,
+	}
+
+	CmdRenameService = &cobra.Command{
+		Use:          "svc",
+		Short:        "Rename a service",
+		Example:      "kun rename svc user member",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runRename,
+	}
+
+	CmdRenameControllerAndService = &cobra.Command{
+		Use:          "cs",
+		Short:        "Rename a controller & service",
+		Example:      "kun rename cs user member",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runRename,
+	}
+
+	CmdRenameRouter = &cobra.Command{
+		Use:          "rt",
+		Short:        "Rename a router",
+		Example:      "kun rename rt user member",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runRename,
+	}
+
+	CmdRenameDBRepository = &cobra.Command{
+		Use:          "db",
+		Short:        "Rename a DB repository",
+		Example:      "kun rename db user_info member_info",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runRename,
+	}
+
+	CmdRenameCacheRepository = &cobra.Command{
+		Use:          "cache",
+		Short:        "Rename a cache repository",
+		Example:      "kun rename cache user member",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runRename,
+	}
+)
+
+func init() {
+	CmdRename.PersistentFlags().BoolVar(&kernel.DryRun, "dry-run", false, "print the renamed files and the diff of modified files without writing")
+	CmdRename.PersistentFlags().BoolVar(&showDiff, "diff", false, "print the renamed files and the diff of modified files after writing")
+}
+
+// renamer 重命名组件的文件和其中声明的标识符
+type renamer struct {
+	oldName, newName string            // 大驼峰的名称, 如 User, Member
+	files            map[string]string // 旧文件的绝对路径 -> 新文件, 包括 mockgen 生成的文件
+	generates        map[string]string // 文件中 go:generate 的旧文件名 -> 新文件名
+	renames          map[string]string // 对象的声明位置 -> 新名称
+	types            map[string]bool   // 重命名的类型的声明位置
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	projectName := helper.GetProjectName(".")
+	if projectName == "" {
+		return fmt.Errorf("run it in the project root")
+	}
+	from, to := NewCreate(), NewCreate()
+	from.ProjectName, to.ProjectName = projectName, projectName
+	from.CmdType, to.CmdType = cmd.Use, cmd.Use
+	if cmd.Use != "db" {
+		if err := from.setName(args[0]); err != nil {
+			return err
+		}
+		if err := to.setName(args[1]); err != nil {
+			return err
+		}
+		if filepath.Clean(from.FilePath) != filepath.Clean(to.FilePath) {
+			return fmt.Errorf("%s and %s must be in the same directory", args[0], args[1])
+		}
+	}
+
+	r := &renamer{
+		oldName:   from.FileName,
+		newName:   to.FileName,
+		files:     make(map[string]string),
+		generates: make(map[string]string),
+		renames:   make(map[string]string),
+		types:     make(map[string]bool),
+	}
+	var names []string
+	switch cmd.Use {
+	case "ctrl":
+		names = []string{TypeController}
+	case "svc":
+		names = []string{TypeService}
+	case "cs":
+		names = []string{TypeController, TypeService}
+	case "rt":
+		names = []string{TypeRouter}
+	case "cache":
+		names = []string{TypeCache}
+	case "db":
+		if err := r.addDBRepo(args[0], args[1]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid type: %s", cmd.Use)
+	}
+	for _, name := range names {
+		config, ok := genConfigs[name]
+		if !ok {
+			return fmt.Errorf("invalid type: %s", name)
+		}
+		from.CreateType, to.CreateType = name, name
+		oldPath, oldFile, err := from.target(config)
+		if err != nil {
+			return err
+		}
+		newPath, newFile, err := to.target(config)
+		if err != nil {
+			return err
+		}
+		if err = r.addFile(filepath.Join(oldPath, oldFile), filepath.Join(newPath, newFile)); err != nil {
+			return err
+		}
+	}
+	if r.oldName == r.newName {
+		return fmt.Errorf("the new name is the same as the old one")
+	}
+
+	pkgs, err := kernel.LoadPackages(".")
+	if err != nil {
+		return err
+	}
+	if err = r.collect(pkgs); err != nil {
+		return err
+	}
+	files, err := kernel.PlanRename(pkgs, r.rename)
+	if err != nil {
+		return err
+	}
+	moved := make(map[string]bool)
+	for _, f := range files {
+		r.renameGenerate(f)
+		abs, _ := filepath.Abs(f.Path)
+		newPath, ok := r.files[abs]
+		if !ok {
+			if err = f.Write(); err != nil {
+				return err
+			}
+			if !kernel.DryRun {
+				fmt.Success("updated %s", relPath(f.Path))
+			}
+			continue
+		}
+		content, err := f.Output()
+		if err != nil {
+			return err
+		}
+		if err = r.move(f.Path, newPath, content); err != nil {
+			return err
+		}
+		moved[abs] = true
+	}
+	// 没有需要重命名的标识符的文件
+	for _, oldPath := range slices.Sorted(maps.Keys(r.files)) {
+		if moved[oldPath] {
+			continue
+		}
+		newPath := r.files[oldPath]
+		f, err := kernel.ParseGoFile(oldPath)
+		if err != nil {
+			return err
+		}
+		r.renameGenerate(f)
+		content, err := f.Output()
+		if err != nil {
+			return err
+		}
+		if err = r.move(oldPath, newPath, content); err != nil {
+			return err
+		}
+	}
+	// dry run 时由 PrintChanges 输出将要修改的文件
+	if !kernel.DryRun {
+		fmt.Success("renamed %s to %s", r.oldName, r.newName)
+	}
+	return nil
+}
+
+// addDBRepo 添加 kun create db 生成的 repository 文件
+func (r *renamer) addDBRepo(oldName, newName string) error {
+	naming := schema.NamingStrategy{SingularTable: true}
+	var structNames []string
+	for _, name := range []string{oldName, newName} {
+		name = strings.TrimSuffix(name, "Db")
+		if strings.ContainsAny(name, `/\.`) {
+			return fmt.Errorf("invalid table name %s", name)
+		}
+		structName := strings.ReplaceAll(naming.SchemaName(name), "ID", "Id")
+		if _, err := helper.GoName(structName); err != nil {
+			return err
+		}
+		structNames = append(structNames, structName)
+	}
+	r.oldName, r.newName = structNames[0], structNames[1]
+	found := false
+	for _, suffix := range []string{".go", "_gen.go"} {
+		oldFile := filepath.Join(DefaultOutPath, helper.LowerCamelCase(r.oldName)+suffix)
+		if !kernel.Exists(oldFile) {
+			continue
+		}
+		if err := r.addFile(oldFile, filepath.Join(DefaultOutPath, helper.LowerCamelCase(r.newName)+suffix)); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("%s does not exist", filepath.ToSlash(filepath.Join(DefaultOutPath, helper.LowerCamelCase(r.oldName)+".go")))
+	}
+	return nil
+}
+
+// addFile 添加重命名的文件及其 mockgen 生成的文件
+func (r *renamer) addFile(oldFile, newFile string) error {
+	if !kernel.Exists(oldFile) {
+		return fmt.Errorf("%s does not exist", filepath.ToSlash(oldFile))
+	}
+	if kernel.Exists(newFile) {
+		return fmt.Errorf("%s already exists", filepath.ToSlash(newFile))
+	}
+	f, err := kernel.ParseGoFile(oldFile)
+	if err != nil {
+		return err
+	}
+	abs, _ := filepath.Abs(f.Path)
+	r.files[abs] = newFile
+	r.generates[filepath.Base(oldFile)] = filepath.Base(newFile)
+
+	dir := filepath.Dir(f.Path)
+	for _, group := range f.File.Comments {
+		for _, comment := range group.List {
+			m := mockDestination.FindStringSubmatch(comment.Text)
+			if m == nil {
+				continue
+			}
+			mock := filepath.Join(dir, m[1])
+			if !kernel.Exists(mock) {
+				continue
+			}
+			newMock := filepath.Join(filepath.Dir(mock), r.generateFile(filepath.Base(mock)))
+			if newMock == mock {
+				continue
+			}
+			if kernel.Exists(newMock) {
+				return fmt.Errorf("%s already exists", filepath.ToSlash(newMock))
+			}
+			abs, _ = filepath.Abs(mock)
+			r.files[abs] = newMock
+		}
+	}
+	return nil
+}
+
+// collect 组件文件中声明的包级别对象按名称重命名
+func (r *renamer) collect(pkgs []*packages.Package) error {
+	for _, pkg := range pkgs {
+		for id, obj := range pkg.TypesInfo.Defs {
+			if obj == nil || obj.Parent() != pkg.Types.Scope() {
+				continue
+			}
+			pos := pkg.Fset.Position(id.Pos())
+			if abs, _ := filepath.Abs(pos.Filename); r.files[abs] == "" {
+				continue
+			}
+			name := r.rename1(obj.Name())
+			if name == "" {
+				continue
+			}
+			if other := pkg.Types.Scope().Lookup(name); other != nil && r.renames[kernel.ObjectKey(pkg, other)] == "" {
+				return fmt.Errorf("%s: %s already declared in package %s", pkg.Fset.Position(other.Pos()), name, pkg.Types.Path())
+			}
+			key := kernel.ObjectKey(pkg, obj)
+			r.renames[key] = name
+			if _, ok := obj.(*types.TypeName); ok {
+				r.types[key] = true
+			}
+		}
+	}
+	return nil
+}
+
+// rename 包级别对象及类型为重命名的类型的字段, 如 ServerCtrlCtx.UserCtrl
+func (r *renamer) rename(pkg *packages.Package, obj types.Object) string {
+	if name, ok := r.renames[kernel.ObjectKey(pkg, obj)]; ok {
+		return name
+	}
+	v, ok := obj.(*types.Var)
+	if !ok || !v.IsField() {
+		return ""
+	}
+	t := v.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || !r.types[kernel.ObjectKey(pkg, named.Obj())] {
+		return ""
+	}
+	return r.rename1(v.Name())
+}
+
+// rename1 名称中最后一个旧名称替换为新名称, 或小写开头的旧名称, 没有时为空
+func (r *renamer) rename1(name string) string {
+	if i := strings.LastIndex(name, r.oldName); i >= 0 {
+		return name[:i] + r.newName + name[i+len(r.oldName):]
+	}
+	if oldLower := helper.LowerCamelCase(r.oldName); strings.HasPrefix(name, oldLower) {
+		return helper.LowerCamelCase(r.newName) + name[len(oldLower):]
+	}
+	return ""
+}
+
+// generateFile mockgen 生成的文件的新文件名
+func (r *renamer) generateFile(name string) string {
+	if newName, ok := r.generates[name]; ok {
+		return newName
+	}
+	return name
+}
+
+// renameGenerate 修改 go:generate 中的文件名
+func (r *renamer) renameGenerate(f *kernel.GoFile) {
+	if abs, _ := filepath.Abs(f.Path); r.files[abs] == "" {
+		return
+	}
+	for _, group := range f.File.Comments {
+		for _, comment := range group.List {
+			if !strings.HasPrefix(comment.Text, "//go:generate") {
+				continue
+			}
+			text := comment.Text
+			for oldName, newName := range r.generates {
+				text = strings.ReplaceAll(text, "/"+oldName, "/"+newName)
+			}
+			if text != comment.Text {
+				f.Replace(comment.Pos(), comment.End(), text)
+			}
+		}
+	}
+}
+
+// move 写入新文件并删除旧文件
+func (r *renamer) move(oldPath, newPath string, content []byte) error {
+	if err := kernel.WriteFile(newPath, content, 0o644); err != nil {
+		return err
+	}
+	if err := kernel.RemoveFile(oldPath); err != nil {
+		return err
+	}
+	if !kernel.DryRun {
+		fmt.Success("renamed %s -> %s", relPath(oldPath), relPath(newPath))
+	}
+	return nil
+}
+
+// relPath 相对于当前目录的路径
+func relPath(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(path)
+}
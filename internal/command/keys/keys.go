@@ -0,0 +1,177 @@
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+// PreviousKey 轮换密钥时旧密钥保存在同级的 previous 下, 如 token.previous.secret
+const PreviousKey = "previous"
+
+var (
+	bits    int
+	outDir  string
+	name    string
+	pkcs8   bool
+	force   bool
+	config  string
+	keys    string
+	length  int
+	dropOld bool
+
+	CmdKeys = &cobra.Command{
+		Use:     "keys",
+		Short:   "Generate RSA key pairs and token secrets",
+		Example: "kun keys gen rsa\n  kun keys gen hmac --config config/local.yml",
+	}
+
+	CmdKeysGen = &cobra.Command{
+		Use:     "gen",
+		Short:   "Generate a RSA key pair or rotate HMAC secrets",
+		Example: "kun keys gen rsa\n  kun keys gen hmac --config config/local.yml",
+	}
+
+	CmdKeysGenRSA = &cobra.Command{
+		Use:          "rsa",
+		Short:        "Generate a RSA key pair in the PEM format of pkg/encrypt",
+		Example:      "kun keys gen rsa --bits 2048 --out keys --name app",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         runRSA,
+	}
+
+	CmdKeysGenHMAC = &cobra.Command{
+		Use:          "hmac",
+		Short:        "Rotate HMAC secrets in a config file, the old ones are kept under previous",
+		Example:      "kun keys gen hmac --config config/release.yml\n  kun keys gen hmac --keys token.secret --length 64",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         runHMAC,
+	}
+)
+
+func init() {
+	CmdKeysGenRSA.Flags().IntVarP(&bits, "bits", "b", 2048, "key size in bits")
+	CmdKeysGenRSA.Flags().StringVarP(&outDir, "out", "o", "keys", "output directory")
+	CmdKeysGenRSA.Flags().StringVarP(&name, "name", "n", "rsa", "file name prefix, writes <name>_private.pem and <name>_public.pem")
+	CmdKeysGenRSA.Flags().BoolVar(&pkcs8, "pkcs8", pkcs8, "write the private key as PKCS#8 instead of PKCS#1")
+	CmdKeysGenRSA.Flags().BoolVarP(&force, "force", "f", force, "overwrite existing key files")
+
+	CmdKeysGenHMAC.Flags().StringVarP(&config, "config", "c", "config/local.yml", "config file")
+	CmdKeysGenHMAC.Flags().StringVarP(&keys, "keys", "k", "token.secret,token.refreshSecret", "secret keys to rotate, separated by comma")
+	CmdKeysGenHMAC.Flags().IntVarP(&length, "length", "l", 32, "length of the new secrets")
+	CmdKeysGenHMAC.Flags().BoolVar(&dropOld, "no-previous", dropOld, "do not keep the old secrets under "+PreviousKey)
+}
+
+func runRSA(_ *cobra.Command, _ []string) error {
+	if bits < 2048 {
+		return fmt.Errorf("--bits must be at least 2048")
+	}
+	priPath := filepath.Join(outDir, name+"_private.pem")
+	pubPath := filepath.Join(outDir, name+"_public.pem")
+	if !force {
+		for _, path := range []string{priPath, pubPath} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+			}
+		}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return err
+	}
+	// 私钥 PKCS#1 或 PKCS#8, 公钥 PKIX, 与 pkg/encrypt 的解析方式一致
+	priBlock := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if pkcs8 {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return err
+		}
+		priBlock = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	pubDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(priPath, pem.EncodeToMemory(priBlock), 0o600); err != nil {
+		return err
+	}
+	if err = os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}), 0o644); err != nil {
+		return err
+	}
+	fmt.Success("RSA private key: %s", priPath)
+	fmt.Success("RSA public key: %s", pubPath)
+	fmt.Warn("Keep the private key out of version control")
+	return nil
+}
+
+func runHMAC(_ *cobra.Command, _ []string) error {
+	if length < 16 {
+		return fmt.Errorf("--length must be at least 16")
+	}
+	data, err := os.ReadFile(config)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("config file %s not found, run it in the project root or use --config", config)
+	}
+	if err != nil {
+		return err
+	}
+	f, err := helper.ParseYaml(data)
+	if err != nil {
+		return fmt.Errorf("parse %s error: %w", config, err)
+	}
+
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		secret, err := helper.RandomString(length)
+		if err != nil {
+			return err
+		}
+		old := f.Lookup(key)
+		if old != nil && old.Value != "" && !dropOld {
+			if err = f.Set(previousPath(key), old.Value); err != nil {
+				return err
+			}
+		}
+		if err = f.Set(key, secret); err != nil {
+			return err
+		}
+		if old != nil && old.Value != "" && !dropOld {
+			fmt.Success("Rotate %s, the old secret is kept in %s", key, previousPath(key))
+		} else {
+			fmt.Success("Generate %s", key)
+		}
+	}
+
+	stat, err := os.Stat(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config, f.Bytes(), stat.Mode().Perm())
+}
+
+// previousPath token.secret 的旧密钥路径为 token.previous.secret
+func previousPath(key string) string {
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return key[:i] + "." + PreviousKey + key[i:]
+	}
+	return PreviousKey + "." + key
+}
@@ -0,0 +1,266 @@
+package new
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"golang.org/x/tools/imports"
+)
+
+// 模板中的组件标记, 写在 // 或 # 注释中:
+//
+//	// kun:if kafka,asynq   任一组件被选择时保留
+//	// kun:if !redis        组件未被选择时保留
+//	// kun:else
+//	// kun:end
+const (
+	// ComponentsNone --components none 不选择任何组件
+	ComponentsNone = "none"
+
+	markerIf   = "kun:if"
+	markerElse = "kun:else"
+	markerEnd  = "kun:end"
+)
+
+// component 模板的可选组件
+type component struct {
+	Name     string
+	Desc     string
+	Requires []string // 依赖的组件
+	Paths    []string // 未选择时删除的文件/目录
+}
+
+// 内置模板的可选组件
+var templateComponents = map[string][]component{
+	LayoutAdvanced: {
+		{
+			Name:  "redis",
+			Desc:  "go-redis client, redis cache repository and token blacklist",
+			Paths: []string{"pkg/xredis", "internal/repository/cache/demo.go"},
+		},
+		{
+			Name:  "kafka",
+			Desc:  "kafka publisher and subscriber",
+			Paths: []string{"pkg/kafka"},
+		},
+		{
+			Name:     "asynq",
+			Desc:     "asynq delay/async/cron tasks",
+			Requires: []string{"redis"},
+			Paths:    []string{"pkg/asynq"},
+		},
+		{
+			Name: "broker",
+			Desc: "broker command consuming kafka/asynq events",
+			Paths: []string{
+				"cmd/broker",
+				"pkg/xserver/broker",
+				"internal/event/subscriber.go",
+				"internal/service/brokerDI.go",
+				"internal/service/svc/broker.go",
+			},
+		},
+		{
+			Name:  "swagger",
+			Desc:  "swagger docs and route",
+			Paths: []string{"docs", "scripts"},
+		},
+		{
+			Name:  "encrypt",
+			Desc:  "pkg/encrypt hash, AES, DES and RSA helpers",
+			Paths: []string{"pkg/encrypt"},
+		},
+	},
+}
+
+// selectComponents 选择模板组件, --components 优先(none 为不选择), --yes 时选择全部
+func (p *Project) selectComponents() error {
+	all := templateComponents[p.Template]
+	if len(all) == 0 {
+		if components != "" {
+			return fmt.Errorf("layout %s has no optional components", p.Template)
+		}
+		return nil
+	}
+	names := make([]string, 0, len(all))
+	for _, c := range all {
+		names = append(names, c.Name)
+	}
+
+	var selected []string
+	switch {
+	case components == ComponentsNone:
+	case components != "":
+		for _, name := range strings.Split(components, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !slices.Contains(names, name) {
+				return fmt.Errorf("invalid component %q, must be in %s", name, strings.Join(names, ","))
+			}
+			selected = append(selected, name)
+		}
+	case assumeYes:
+		selected = names
+	default:
+		prompt := &survey.MultiSelect{
+			Message: "Please select components:",
+			Options: names,
+			Default: names,
+			Description: func(value string, index int) string {
+				return all[index].Desc
+			},
+		}
+		if err := ask(prompt, &selected, "components (--components a,b|none)"); err != nil {
+			return err
+		}
+	}
+
+	// 补齐依赖的组件
+	for i := 0; i < len(selected); i++ {
+		for _, c := range all {
+			if c.Name != selected[i] {
+				continue
+			}
+			for _, r := range c.Requires {
+				if !slices.Contains(selected, r) {
+					fmt.Warn("component %s requires %s, %s added", c.Name, r, r)
+					selected = append(selected, r)
+				}
+			}
+		}
+	}
+	p.Components = selected
+	return nil
+}
+
+// pruneComponents 删除未选择组件的文件, 并按标记裁剪代码和配置
+func (p *Project) pruneComponents() error {
+	all := templateComponents[p.Template]
+	if len(all) == 0 {
+		return nil
+	}
+	for _, c := range all {
+		if slices.Contains(p.Components, c.Name) {
+			continue
+		}
+		for _, path := range c.Paths {
+			if err := os.RemoveAll(filepath.Join(p.Dir, filepath.FromSlash(path))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return filepath.Walk(p.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !info.Mode().IsRegular() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if isBinary(data) || !bytes.Contains(data, []byte(markerIf)) {
+			return nil
+		}
+		newData, err := pruneMarkers(data, p.Components)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		// 删除代码块后 import 可能不再使用, 由 imports 清理并格式化
+		if filepath.Ext(path) == ".go" {
+			if newData, err = imports.Process(path, newData, nil); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return os.WriteFile(path, newData, info.Mode().Perm())
+	})
+}
+
+// pruneMarkers 按组件标记保留或删除代码块, 标记行本身会被删除
+func pruneMarkers(data []byte, selected []string) ([]byte, error) {
+	var (
+		out   bytes.Buffer
+		stack []bool // 每层是否保留
+		line  int
+	)
+	keep := func() bool {
+		for _, k := range stack {
+			if !k {
+				return false
+			}
+		}
+		return true
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		marker, expr := parseMarker(text)
+		switch marker {
+		case markerIf:
+			stack = append(stack, componentsMatch(expr, selected))
+		case markerElse:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: %s without %s", line, markerElse, markerIf)
+			}
+			stack[len(stack)-1] = !stack[len(stack)-1]
+		case markerEnd:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: %s without %s", line, markerEnd, markerIf)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if keep() {
+				out.WriteString(text)
+				out.WriteByte('\n')
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("%s is not closed", markerIf)
+	}
+	return out.Bytes(), nil
+}
+
+// parseMarker 解析 // kun:if xxx 或 # kun:if xxx 形式的标记行
+func parseMarker(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "//"):
+		text = strings.TrimSpace(text[2:])
+	case strings.HasPrefix(text, "#"):
+		text = strings.TrimSpace(text[1:])
+	default:
+		return "", ""
+	}
+	for _, m := range []string{markerIf, markerElse, markerEnd} {
+		if text == m || strings.HasPrefix(text, m+" ") {
+			return m, strings.TrimSpace(text[len(m):])
+		}
+	}
+	return "", ""
+}
+
+// componentsMatch a,b 任一被选择时成立, !a 未被选择时成立
+func componentsMatch(expr string, selected []string) bool {
+	if strings.HasPrefix(expr, "!") {
+		return !slices.Contains(selected, strings.TrimSpace(expr[1:]))
+	}
+	for _, name := range strings.Split(expr, ",") {
+		if slices.Contains(selected, strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
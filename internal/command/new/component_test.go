@@ -0,0 +1,73 @@
+package new
+
+import "testing"
+
+func TestPruneMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		selected []string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "keep selected",
+			data:     "a\n// kun:if redis\nb\n// kun:end\nc\n",
+			selected: []string{"redis"},
+			want:     "a\nb\nc\n",
+		},
+		{
+			name: "drop unselected",
+			data: "a\n// kun:if redis\nb\n// kun:end\nc\n",
+			want: "a\nc\n",
+		},
+		{
+			name:     "any of the list",
+			data:     "// kun:if kafka,asynq\nb\n// kun:end\n",
+			selected: []string{"asynq"},
+			want:     "b\n",
+		},
+		{
+			name:     "negation",
+			data:     "// kun:if !redis\nlocal\n// kun:else\nredis\n// kun:end\n",
+			selected: []string{"redis"},
+			want:     "redis\n",
+		},
+		{
+			name: "else",
+			data: "// kun:if redis\nredis\n// kun:else\nlocal\n// kun:end\n",
+			want: "local\n",
+		},
+		{
+			name:     "nested",
+			data:     "# kun:if broker\nb\n  # kun:if kafka\n  k\n  # kun:end\n# kun:end\n",
+			selected: []string{"broker"},
+			want:     "b\n",
+		},
+		{
+			name:     "yaml comment",
+			data:     "redis:\n  # kun:if redis\n  addr: 127.0.0.1\n  # kun:end\n",
+			selected: []string{"redis"},
+			want:     "redis:\n  addr: 127.0.0.1\n",
+		},
+		{
+			name: "marker prefix is not a marker",
+			data: "// kun:ifx redis\n",
+			want: "// kun:ifx redis\n",
+		},
+		{name: "end without if", data: "// kun:end\n", wantErr: true},
+		{name: "else without if", data: "// kun:else\n", wantErr: true},
+		{name: "if not closed", data: "// kun:if redis\na\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pruneMarkers([]byte(tt.data), tt.selected)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pruneMarkers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("pruneMarkers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package new
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/spruce1698/kun/config"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+// hook 生成项目后按顺序执行的钩子, run/copy/remove 三选一, 在项目根目录中执行
+//
+//	hooks:
+//	  - run: go mod tidy
+//	  - run: go generate ./...
+//	  - copy: .env.example
+//	    to: .env
+//	  - run: git init
+//	  - run: git add -A
+//	  - run: git commit -m "init"
+//	    optional: true
+type hook struct {
+	Name      string `yaml:"name"`
+	Run       string `yaml:"run"`    // 命令, 按 shell 规则拆分参数, 不经过 shell 执行
+	Copy      string `yaml:"copy"`   // 复制项目中的文件
+	To        string `yaml:"to"`     // copy 的目标
+	Remove    string `yaml:"remove"` // 删除项目中的文件/目录
+	condition `yaml:",inline"`
+	Optional  bool `yaml:"optional"` // 失败时只警告, 不中断生成
+}
+
+// 模板未声明钩子时执行的默认钩子
+var defaultHooks = []hook{
+	{Run: "go mod tidy"},
+	{Name: "remove .git", Remove: ".git"},
+	{Run: "go install " + config.WireUrl, Optional: true},
+}
+
+func (h *hook) String() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case h.Run != "":
+		return h.Run
+	case h.Copy != "":
+		return "copy " + h.Copy + " to " + h.To
+	}
+	return "remove " + h.Remove
+}
+
+// validate 校验钩子, 路径必须在项目目录内
+func (h *hook) validate() error {
+	n := 0
+	for _, s := range []string{h.Run, h.Copy, h.Remove} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("hook %s must have exactly one of run, copy or remove", h)
+	}
+	if h.Run != "" {
+		args, err := shellquote.Split(h.Run)
+		if err != nil {
+			return fmt.Errorf("hook %s: %w", h, err)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("hook %s: run is empty", h)
+		}
+	}
+	if h.Copy != "" && h.To == "" {
+		return fmt.Errorf("hook %s: copy needs to", h)
+	}
+	for _, path := range []string{h.Copy, h.To, h.Remove} {
+		if path != "" && !filepath.IsLocal(filepath.FromSlash(path)) {
+			return fmt.Errorf("hook %s: path %s must be inside the project", h, path)
+		}
+	}
+	return nil
+}
+
+// hooks 模板清单中声明的钩子, 未声明时为默认钩子
+func (p *Project) hooks() []hook {
+	if p.manifest != nil && p.manifest.Hooks != nil {
+		return p.manifest.Hooks
+	}
+	return defaultHooks
+}
+
+// runHooks 依次执行钩子并输出结果, --no-hooks 时跳过
+func (p *Project) runHooks() error {
+	var hooks []hook
+	for _, h := range p.hooks() {
+		if h.When == "" || h.matched(p.Vars) {
+			hooks = append(hooks, h)
+		}
+	}
+	if noHooks {
+		// 组件的依赖由 go mod tidy 清理, 跳过时 go.mod 中仍保留
+		if len(p.Components) < len(templateComponents[p.Template]) {
+			fmt.Warn("go.mod still requires the modules of the unselected components, run go mod tidy to drop them")
+		}
+		if len(hooks) > 0 {
+			fmt.Warn("Skip %d hook(s), run them yourself if needed:", len(hooks))
+			for _, h := range hooks {
+				fmt.Warn("› %s", h.String())
+			}
+		}
+		return nil
+	}
+	for _, h := range hooks {
+		fmt.Success("%s", h.String())
+		out, err := p.runHook(&h)
+		if out = bytes.TrimSpace(out); len(out) > 0 {
+			fmt.Print("%s", strings.ReplaceAll(string(out), "\n", "\n     "))
+		}
+		if err == nil {
+			continue
+		}
+		if h.Optional {
+			fmt.Warn("hook %s error: %s", h.String(), err)
+			continue
+		}
+		return &stepError{Step: "hook " + h.String(), Err: err}
+	}
+	return nil
+}
+
+func (p *Project) runHook(h *hook) ([]byte, error) {
+	switch {
+	case h.Copy != "":
+		from := filepath.Join(p.Dir, filepath.FromSlash(h.Copy))
+		stat, err := os.Stat(from)
+		if err != nil {
+			return nil, err
+		}
+		if stat.IsDir() {
+			return nil, helper.CopyDir(from, filepath.Join(p.Dir, filepath.FromSlash(h.To)))
+		}
+		data, err := os.ReadFile(from)
+		if err != nil {
+			return nil, err
+		}
+		to := filepath.Join(p.Dir, filepath.FromSlash(h.To))
+		if err = os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return nil, err
+		}
+		return nil, os.WriteFile(to, data, stat.Mode().Perm())
+	case h.Remove != "":
+		return nil, os.RemoveAll(filepath.Join(p.Dir, filepath.FromSlash(h.Remove)))
+	}
+	args, _ := shellquote.Split(h.Run)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Env = append(os.Environ(),
+		"KUN_PROJECT_NAME="+filepath.Base(filepath.Clean(p.ProjectName)),
+		"KUN_MODULE_PATH="+p.ModulePath,
+	)
+	return cmd.CombinedOutput()
+}
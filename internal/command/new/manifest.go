@@ -0,0 +1,308 @@
+package new
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile 模板根目录下的清单文件, 声明变量、渲染文件和条件规则, 生成后删除
+const ManifestFile = "kun.template.yaml"
+
+const (
+	VarTypeString = "string"
+	VarTypeBool   = "bool"
+	VarTypeSelect = "select"
+)
+
+// manifest 模板清单
+//
+//	variables:
+//	  - name: ServiceName
+//	    prompt: "What is the service name?"
+//	    default: "api"
+//	    validate: "^[a-z][a-z0-9-]*$"
+//	  - name: Kafka
+//	    type: bool
+//	    default: "false"
+//	render:
+//	  - "config/*.yml"
+//	rules:
+//	  - when: Kafka
+//	    include: ["pkg/kafka/**"]
+//	hooks:
+//	  - run: go mod tidy
+type manifest struct {
+	Variables []*variable   `yaml:"variables"`
+	Render    []string      `yaml:"render"`  // 通过 text/template 渲染的文件
+	Rules     []includeRule `yaml:"rules"`   // 按变量保留/删除文件
+	Rewrite   []rewriteRule `yaml:"rewrite"` // 改名规则, 为空时使用默认规则
+	Hooks     []hook        `yaml:"hooks"`   // 生成后执行的钩子, 未声明时使用默认钩子, 声明为空列表时不执行
+	Secrets   []secretRule  `yaml:"secrets"` // 替换为随机值的密钥
+}
+
+// variable 模板变量
+type variable struct {
+	Name     string   `yaml:"name"`
+	Prompt   string   `yaml:"prompt"`
+	Help     string   `yaml:"help"`
+	Type     string   `yaml:"type"` // string(默认) | bool | select
+	Default  string   `yaml:"default"`
+	Options  []string `yaml:"options"` // select 的可选值
+	Required bool     `yaml:"required"`
+	Validate string   `yaml:"validate"` // 正则
+}
+
+// condition 按变量判断的条件
+type condition struct {
+	When   string `yaml:"when"`   // 变量名, bool 为 true 或字符串非空时成立
+	Equals string `yaml:"equals"` // 不为空时, 变量等于该值才成立
+}
+
+// includeRule 条件规则: 条件成立时删除 Exclude, 不成立时删除 Include
+type includeRule struct {
+	condition `yaml:",inline"`
+	Include   []string `yaml:"include"`
+	Exclude   []string `yaml:"exclude"`
+}
+
+// loadManifest 读取模板清单, 不存在时返回 nil
+func loadManifest(root string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(root, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err = yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", ManifestFile, err)
+	}
+	for _, v := range m.Variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("%s: variable name cannot be empty", ManifestFile)
+		}
+		switch v.Type {
+		case "":
+			v.Type = VarTypeString
+		case VarTypeString, VarTypeBool, VarTypeSelect:
+		default:
+			return nil, fmt.Errorf("%s: variable %s has invalid type %q", ManifestFile, v.Name, v.Type)
+		}
+		if v.Type == VarTypeSelect && len(v.Options) == 0 {
+			return nil, fmt.Errorf("%s: variable %s needs options", ManifestFile, v.Name)
+		}
+		if v.Validate != "" {
+			if _, err = regexp.Compile(v.Validate); err != nil {
+				return nil, fmt.Errorf("%s: variable %s has invalid validate: %w", ManifestFile, v.Name, err)
+			}
+		}
+	}
+	for i := range m.Hooks {
+		if err = m.Hooks[i].validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+		}
+	}
+	return m, nil
+}
+
+// parseVars 解析 --var key=value
+func parseVars(pairs []string) (map[string]string, error) {
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --var %q, must be key=value", pair)
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+// askVariables 依次取得变量值: --var 优先, 其次交互式询问, --yes 时使用默认值
+func (p *Project) askVariables(m *manifest, given map[string]string) error {
+	for _, v := range m.Variables {
+		raw, ok := given[v.Name]
+		if !ok {
+			if assumeYes && (v.Default != "" || !v.Required) {
+				raw = v.Default
+			} else {
+				var err error
+				if raw, err = v.ask(); err != nil {
+					return err
+				}
+			}
+		}
+		value, err := v.parse(raw)
+		if err != nil {
+			return err
+		}
+		p.Vars[v.Name] = value
+	}
+	return nil
+}
+
+func (v *variable) ask() (string, error) {
+	message := v.Prompt
+	if message == "" {
+		message = v.Name + ":"
+	}
+	what := fmt.Sprintf("variable %s (--var %s=...)", v.Name, v.Name)
+	switch v.Type {
+	case VarTypeBool:
+		def, _ := strconv.ParseBool(v.Default)
+		answer := def
+		err := ask(&survey.Confirm{Message: message, Help: v.Help, Default: def}, &answer, what)
+		return strconv.FormatBool(answer), err
+	case VarTypeSelect:
+		answer := ""
+		prompt := &survey.Select{Message: message, Help: v.Help, Options: v.Options}
+		if v.Default != "" {
+			prompt.Default = v.Default
+		}
+		err := ask(prompt, &answer, what)
+		return answer, err
+	default:
+		answer := ""
+		opts := []survey.AskOpt{survey.WithValidator(func(ans any) error {
+			_, err := v.parse(ans.(string))
+			return err
+		})}
+		err := ask(&survey.Input{Message: message, Help: v.Help, Default: v.Default}, &answer, what, opts...)
+		return answer, err
+	}
+}
+
+// parse 校验并转换变量值, bool 变量转换为 bool, 其余为 string
+func (v *variable) parse(raw string) (any, error) {
+	switch v.Type {
+	case VarTypeBool:
+		if raw == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s must be true or false, got %q", v.Name, raw)
+		}
+		return b, nil
+	case VarTypeSelect:
+		for _, o := range v.Options {
+			if o == raw {
+				return raw, nil
+			}
+		}
+		return nil, fmt.Errorf("variable %s must be one of %s, got %q", v.Name, strings.Join(v.Options, "|"), raw)
+	}
+	if raw == "" && v.Required {
+		return nil, fmt.Errorf("variable %s is required", v.Name)
+	}
+	if v.Validate != "" && raw != "" && !regexp.MustCompile(v.Validate).MatchString(raw) {
+		return nil, fmt.Errorf("variable %s must match %s, got %q", v.Name, v.Validate, raw)
+	}
+	return raw, nil
+}
+
+// matched 条件是否成立
+func (c condition) matched(vars map[string]any) bool {
+	value := vars[c.When]
+	if c.Equals != "" {
+		return fmt.Sprintf("%v", value) == c.Equals
+	}
+	switch t := value.(type) {
+	case bool:
+		return t
+	case string:
+		return t != ""
+	}
+	return false
+}
+
+// applyManifest 按清单删除不需要的文件, 渲染模板文件, 最后删除清单
+func (p *Project) applyManifest(m *manifest) error {
+	var remove []string
+	for _, r := range m.Rules {
+		if r.matched(p.Vars) {
+			remove = append(remove, r.Exclude...)
+		} else {
+			remove = append(remove, r.Include...)
+		}
+	}
+
+	data := map[string]any{
+		"ProjectName": filepath.Base(filepath.Clean(p.ProjectName)),
+		"ModulePath":  p.ModulePath,
+	}
+	for k, v := range p.Vars {
+		data[k] = v
+	}
+
+	err := filepath.Walk(p.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(p.Dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		for _, pattern := range remove {
+			if helper.MatchPath(pattern, rel) {
+				if info.IsDir() {
+					return removeDir(path)
+				}
+				return os.Remove(path)
+			}
+		}
+		if info.IsDir() || !info.Mode().IsRegular() {
+			return nil
+		}
+		for _, pattern := range m.Render {
+			if helper.MatchPath(pattern, rel) {
+				return renderFile(path, rel, info.Mode().Perm(), data)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(p.Dir, ManifestFile))
+}
+
+// removeDir 删除目录并跳过遍历
+func removeDir(path string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return filepath.SkipDir
+}
+
+func renderFile(path, name string, perm os.FileMode, data map[string]any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	t, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("parse template %s error: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return fmt.Errorf("render template %s error: %w", name, err)
+	}
+	return os.WriteFile(path, buf.Bytes(), perm)
+}
@@ -3,233 +3,343 @@ package new
 import (
 	"archive/zip"
 	"bytes"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
-	"github.com/spruce1698/kun/config"
+	"github.com/spruce1698/kun/internal/command/template"
 	"github.com/spruce1698/kun/pkg/fmt"
 	"github.com/spruce1698/kun/pkg/helper"
 	"github.com/spruce1698/kun/tpl"
+	"golang.org/x/mod/module"
 )
 
 type Project struct {
-	ProjectName string `survey:"name"`
+	ProjectName string         `survey:"name"` // 项目目录
+	Dir         string         // 生成中的临时目录, 全部步骤成功后移动到项目目录
+	ModulePath  string         // go module 路径, 默认取项目目录名
+	Template    string         // 内置模板名, git 模板为空
+	Components  []string       // 选择的模板组件
+	Vars        map[string]any // 模板清单中声明的变量值
+	Origin      origin         // 模板来源
+	manifest    *manifest
+	local       *template.Template
 }
 
 var CmdNew = &cobra.Command{
-	Use:     "new",
-	Example: "kun new demo",
-	Short:   "create a new project.",
-	Long:    `create a new project with kun layout.`,
-	Run:     run,
+	Use:          "new",
+	Example:      "kun new demo\n  kun new demo --module git.company.com/team/demo\n  kun new demo --layout basic --force --yes\n  kun new demo -g https://github.com/foo/layouts.git --ref v1.0.0 --subdir api",
+	Short:        "create a new project.",
+	Long:         `create a new project with kun layout.`,
+	SilenceUsage: true,
+	RunE:         run,
 }
 var (
-	repoURL string
+	repoURL     string
+	registered  string
+	gitRef      string
+	gitSubdir   string
+	modulePath  string
+	layout      string
+	force       bool
+	noOverwrite bool
+	assumeYes   bool
+	templateVar []string
+	components  string
+	noHooks     bool
+)
+
+const (
+	LayoutBasic    = "basic"
+	LayoutAdvanced = "advanced"
 )
 
 func init() {
 	CmdNew.Flags().StringVarP(&repoURL, "repo-url", "g", repoURL, "layout repo")
+	CmdNew.Flags().StringVar(&gitRef, "ref", gitRef, "branch, tag or commit of the layout repo")
+	CmdNew.Flags().StringVar(&gitSubdir, "subdir", gitSubdir, "directory of the layout inside the layout repo")
+	CmdNew.Flags().StringVarP(&registered, "template", "t", registered, "template registered by kun template add")
+	CmdNew.Flags().StringVarP(&modulePath, "module", "m", modulePath, "go module path, default is the project folder name")
+	CmdNew.Flags().StringVarP(&layout, "layout", "l", layout, "layout: basic|advanced")
+	CmdNew.Flags().StringVarP(&components, "components", "c", components, "components of the advanced layout: redis,kafka,asynq,broker,swagger,encrypt or none, default all")
+	CmdNew.Flags().BoolVarP(&force, "force", "f", force, "overwrite the existing folder without asking")
+	CmdNew.Flags().BoolVar(&noOverwrite, "no-overwrite", noOverwrite, "fail when the folder already exists")
+	CmdNew.Flags().BoolVarP(&assumeYes, "yes", "y", assumeYes, "never prompt, use flags or defaults and fail when input is missing")
+	CmdNew.Flags().StringArrayVar(&templateVar, "var", templateVar, "template variable key=value declared in "+ManifestFile+", repeatable")
+	CmdNew.Flags().BoolVar(&noHooks, "no-hooks", noHooks, "skip the post-generation hooks (go mod tidy, install wire or hooks declared in "+ManifestFile+")")
+	CmdNew.MarkFlagsMutuallyExclusive("force", "no-overwrite")
+	CmdNew.MarkFlagsMutuallyExclusive("repo-url", "template")
 }
 
 func NewProject() *Project {
-	return &Project{}
+	return &Project{Vars: make(map[string]any)}
 }
 
-func run(_ *cobra.Command, args []string) {
+func run(_ *cobra.Command, args []string) error {
 	p := NewProject()
 	switch len(args) {
 	case 0:
-		err := survey.AskOne(&survey.Input{
+		err := ask(&survey.Input{
 			Message: "What is your project name?",
 			Help:    "project name.",
 			Suggest: nil,
-		}, &p.ProjectName, survey.WithValidator(survey.Required))
+		}, &p.ProjectName, "project name", survey.WithValidator(survey.Required))
 		if err != nil {
-			return
+			return err
 		}
 	case 1:
 		p.ProjectName = args[0]
 	default:
-		fmt.Error("accepts %d arg(s), received %d", 1, len(args))
-		return
+		return fmt.Errorf("accepts %d arg(s), received %d", 1, len(args))
 	}
-
-	// clone repo
-	yes, err := p.cloneTemplate()
-	if err != nil || !yes {
-		return
+	if err := p.setModulePath(modulePath); err != nil {
+		return err
+	}
+	given, err := parseVars(templateVar)
+	if err != nil {
+		return err
 	}
 
-	err = p.replacePackageName()
+	yes, err := p.prepare()
 	if err != nil || !yes {
-		return
+		return err
+	}
+	// 在临时目录中生成, 任一步骤失败时删除临时目录, 已存在的项目目录保持不变
+	if err = p.stage(); err != nil {
+		return fmt.Errorf("create temp folder error: %w", err)
 	}
+	defer p.cleanup()
 
-	err = p.replacePackageName()
-	if err != nil || !yes {
-		return
+	steps := []step{
+		{Name: "generate code from template", Run: p.generate},
+		{Name: "render template", Run: func() error { return p.customize(given) }},
+		{Name: "replace module name", Run: p.replacePackageName},
+		{Name: "generate secrets", Run: p.generateSecrets},
+		{Name: "record template origin", Run: p.writeOrigin},
+		{Name: "run hooks", Run: p.runHooks},
+		{Name: "move project into place", Run: p.moveIntoPlace},
 	}
-	err = p.modTidy()
-	if err != nil || !yes {
-		return
+	for _, s := range steps {
+		if err = s.Run(); err != nil {
+			return p.fail(s.Name, err)
+		}
 	}
-	p.rmGit()
-	p.installWire()
 	fmt.Success("Project [ %s ] created successfully!", p.ProjectName)
 	fmt.Success("Done. Now run:")
 	fmt.Success("› cd %s ", p.ProjectName)
 	fmt.Success("› kun run \n")
+	return nil
 }
 
-func (p *Project) cloneTemplate() (bool, error) {
+// setModulePath 设置 go module 路径, 为空时由项目目录名得出
+func (p *Project) setModulePath(path string) error {
+	if path == "" {
+		path = filepath.Base(filepath.Clean(p.ProjectName))
+	}
+	if err := module.CheckImportPath(path); err != nil {
+		return fmt.Errorf("invalid module path: %w", err)
+	}
+	p.ModulePath = path
+	return nil
+}
 
+// ask 交互式询问, --yes 或标准输入不是终端时直接返回错误, 不会阻塞在 stdin
+func ask(prompt survey.Prompt, response any, what string, opts ...survey.AskOpt) error {
+	if assumeYes || !helper.IsInteractive() {
+		return fmt.Errorf("%s is required in non-interactive mode", what)
+	}
+	return survey.AskOne(prompt, response, opts...)
+}
+
+// overwrite 目录已存在时是否覆盖, --force 直接覆盖, --no-overwrite 和 --yes(默认不覆盖)返回错误
+func (p *Project) overwrite() (bool, error) {
 	stat, _ := os.Stat(p.ProjectName)
-	if stat != nil {
-		var overwrite = false
+	if stat == nil || force {
+		return true, nil
+	}
+	if noOverwrite || assumeYes {
+		return false, fmt.Errorf("folder %s already exists, use --force to overwrite it", p.ProjectName)
+	}
 
-		prompt := &survey.Confirm{
-			Message: fmt.Sprintf("Folder %s already exists, do you want to overwrite it?", p.ProjectName),
-			Help:    "Remove old project and create new project.",
-		}
-		err := survey.AskOne(prompt, &overwrite)
-		if err != nil {
-			return false, err
-		}
-		if !overwrite {
-			return false, nil
-		}
-		err = os.RemoveAll(p.ProjectName)
+	var overwrite = false
+	prompt := &survey.Confirm{
+		Message: fmt.Sprintf("Folder %s already exists, do you want to overwrite it?", p.ProjectName),
+		Help:    "Remove old project and create new project.",
+	}
+	err := ask(prompt, &overwrite, "overwrite confirmation (--force or --no-overwrite)")
+	if err != nil {
+		return false, err
+	}
+	if !overwrite {
+		fmt.Warn("Folder %s already exists, skip.", p.ProjectName)
+	}
+	return overwrite, nil
+}
+
+// selectLayout 选择内置模板, --layout 优先, --yes 时使用默认的 advanced
+func (p *Project) selectLayout() (string, error) {
+	switch strings.ToLower(layout) {
+	case LayoutBasic:
+		return LayoutBasic, nil
+	case LayoutAdvanced:
+		return LayoutAdvanced, nil
+	case "":
+	default:
+		return "", fmt.Errorf("invalid layout %q, must be %s or %s", layout, LayoutBasic, LayoutAdvanced)
+	}
+	if assumeYes {
+		return LayoutAdvanced, nil
+	}
+
+	selected := ""
+	prompt := &survey.Select{
+		Message: "Please select a layout:",
+		Options: []string{
+			"Advanced",
+			"Basic",
+		},
+		Description: func(value string, index int) string {
+			if index == 1 {
+				return "A basic project structure"
+			}
+			return "It has rich functions such as db, jwt, cron, migration, test, etc"
+		},
+	}
+	err := ask(prompt, &selected, "layout (--layout basic|advanced)")
+	if err != nil {
+		return "", err
+	}
+	if selected == "Basic" {
+		return LayoutBasic, nil
+	}
+	return LayoutAdvanced, nil
+}
+
+// prepare 校验参数, 选择模板并确认是否覆盖已存在的目录
+func (p *Project) prepare() (bool, error) {
+	if (repoURL != "" || registered != "") && (layout != "" || components != "") {
+		return false, fmt.Errorf("--layout and --components cannot be used with --repo-url or --template")
+	}
+	if repoURL == "" && (gitRef != "" || gitSubdir != "") {
+		return false, fmt.Errorf("--ref and --subdir can only be used with --repo-url")
+	}
+	if registered != "" {
+		t, err := template.Lookup(registered)
 		if err != nil {
-			fmt.Error("remove old project error: %s", err)
 			return false, err
 		}
+		p.local = t
 	}
 
-	if repoURL == "" {
-		layout := ""
-		prompt := &survey.Select{
-			Message: "Please select a layout:",
-			Options: []string{
-				"Advanced",
-				"Basic",
-			},
-			Description: func(value string, index int) string {
-				if index == 1 {
-					return "A basic project structure"
-				}
-				return "It has rich functions such as db, jwt, cron, migration, test, etc"
-			},
-		}
-		err := survey.AskOne(prompt, &layout)
-		if err != nil {
+	yes, err := p.overwrite()
+	if err != nil || !yes {
+		return false, err
+	}
+	if p.local == nil && repoURL == "" {
+		if p.Template, err = p.selectLayout(); err != nil {
 			return false, err
 		}
-		err = os.RemoveAll(p.ProjectName)
-		if err != nil {
-			fmt.Error("remove old project error: %s", err)
+		if err = p.selectComponents(); err != nil {
 			return false, err
 		}
+	}
+	return true, nil
+}
 
-		templateName := "basic"
-		if layout != "Basic" {
-			templateName = "advanced"
+// generate 把模板生成到临时目录
+func (p *Project) generate() error {
+	switch {
+	case p.local != nil: // 本地模板仓库
+		fmt.Success("Generate code from template: %s (%s)", p.local.Name, p.local.Version())
+		if err := helper.CopyDir(p.local.Dir(), p.Dir); err != nil {
+			return fmt.Errorf("generate code from template: %s, error: %w", p.local.Name, err)
+		}
+		p.Origin = origin{
+			Template: p.local.Name,
+			Source:   p.local.Source,
+			Ref:      p.local.Ref,
+			Subdir:   p.local.Subdir,
+			Commit:   p.local.Commit,
 		}
 
-		fmt.Success("Generate code from template: %s", templateName)
+	case repoURL == "":
+		fmt.Success("Generate code from template: %s", p.Template)
 
-		err = handlerZip(p.ProjectName, templateName)
+		err := handlerZip(p.Dir, p.Template)
 		if err != nil {
-			fmt.Error("Generate code from template: %s, error: %s", templateName, err)
-			return false, err
+			return fmt.Errorf("generate code from template: %s, error: %w", p.Template, err)
+		}
+		if err = p.pruneComponents(); err != nil {
+			return fmt.Errorf("prune components error: %w", err)
 		}
 
-	} else { // clone from repoURL
+	default: // clone from repoURL
 		fmt.Success("git clone %s", repoURL)
-		cmd := exec.Command("git", "clone", repoURL, p.ProjectName)
-		_, err := cmd.CombinedOutput()
+		commit, err := template.Clone(repoURL, gitRef, gitSubdir, p.Dir)
 		if err != nil {
-			fmt.Error("git clone %s error: %s", repoURL, err)
-			return false, err
+			return err
 		}
+		fmt.Success("Generate code from %s@%s", repoURL, commit)
+		p.Origin = origin{Source: repoURL, Ref: gitRef, Subdir: gitSubdir, Commit: commit}
 	}
-	return true, nil
+	return nil
 }
 
-func (p *Project) replacePackageName() error {
-	packageName := helper.GetProjectName(p.ProjectName)
-
-	err := p.replaceFiles(packageName)
-	if err != nil {
+// customize 模板带有清单时, 取得变量值并渲染模板
+func (p *Project) customize(given map[string]string) error {
+	m, err := loadManifest(p.Dir)
+	if err != nil || m == nil {
 		return err
 	}
-
-	cmd := exec.Command("go", "mod", "edit", "-module", p.ProjectName)
-	cmd.Dir = p.ProjectName
-	_, err = cmd.CombinedOutput()
-	if err != nil {
-		fmt.Error("go mod edit error: %s", err)
+	p.manifest = m
+	if err = p.askVariables(m, given); err != nil {
 		return err
 	}
-	return nil
-}
-func (p *Project) modTidy() error {
-	fmt.Success("go mod tidy")
-	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Dir = p.ProjectName
-	if err := cmd.Run(); err != nil {
-		fmt.Error("go mod tidy error: %s", err)
-		return err
+	fmt.Success("Render template with %s", ManifestFile)
+	if err = p.applyManifest(m); err != nil {
+		return fmt.Errorf("render template error: %w", err)
 	}
 	return nil
 }
-func (p *Project) rmGit() {
-	_ = os.RemoveAll(p.ProjectName + "/.git")
-}
-func (p *Project) installWire() {
-	fmt.Success("go install %s", config.WireUrl)
-	cmd := exec.Command("go", "install", config.WireUrl)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Error("go install %s error", err)
+
+func (p *Project) replacePackageName() error {
+	packageName := helper.GetProjectName(p.Dir)
+	if packageName == "" {
+		return fmt.Errorf("cannot read the module name of the template")
+	}
+
+	err := p.replaceFiles(packageName)
+	if err != nil {
+		return err
 	}
+
+	return p.command("go", "mod", "edit", "-module", p.ModulePath)
 }
 
+// replaceFiles 把模板原 module 名改写为新项目的 module 路径/名称
 func (p *Project) replaceFiles(packageName string) error {
-	err := filepath.Walk(p.ProjectName, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-		if filepath.Ext(path) != ".go" {
-			return nil
-		}
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-		newData := bytes.ReplaceAll(data, []byte(packageName), []byte(p.ProjectName))
-		if err := os.WriteFile(path, newData, 0644); err != nil {
-			return err
-		}
-		return nil
-	})
+	rules, ok := templateRewrites[p.Template]
+	if p.manifest != nil && len(p.manifest.Rewrite) > 0 {
+		rules = p.manifest.Rewrite
+	} else if !ok {
+		rules = defaultRewrites
+	}
+	compiled, err := compileRewrites(rules, packageName)
 	if err != nil {
-		fmt.Error("walk file error: %s", err)
 		return err
 	}
+	err = rewriteFiles(p.Dir, compiled, p.ModulePath, filepath.Base(filepath.Clean(p.ProjectName)))
+	if err != nil {
+		return fmt.Errorf("walk file error: %w", err)
+	}
 	return nil
 }
 
 func handlerZip(projectName, templateName string) error {
 	// 创建项目目录
-	mkDirErr := os.MkdirAll(projectName, os.ModeExclusive)
+	mkDirErr := os.MkdirAll(projectName, 0o755)
 	if mkDirErr != nil {
 		return mkDirErr
 	}
@@ -243,45 +353,7 @@ func handlerZip(projectName, templateName string) error {
 		return zipReaderErr
 	}
 
-	// 遍历 zip 包里的文件
-	for _, file := range zipReader.File {
-		path := filepath.Join(projectName, file.Name)
-		fileMode := file.Mode()
-		// 如果是目录，就创建目录
-		if file.FileInfo().IsDir() {
-			if tempMkDirErr := os.MkdirAll(path, fileMode); tempMkDirErr != nil {
-				return tempMkDirErr
-			}
-			// 因为是目录，跳过当前循环，因为后面都是文件的处理
-			continue
-		}
-
-		// 获取到 Reader
-		fr, frErr := file.Open()
-		if frErr != nil {
-			_ = fr.Close()
-			return frErr
-		}
-
-		// 创建要写出的文件对应的 Write
-		fw, fwErr := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, fileMode)
-		if fwErr != nil {
-			_ = fw.Close()
-			_ = fr.Close()
-			return fwErr
-		}
-
-		_, copyErr := io.Copy(fw, fr)
-		if copyErr != nil {
-			_ = fw.Close()
-			_ = fr.Close()
-			return copyErr
-		}
-		_ = fw.Close()
-		_ = fr.Close()
-	}
-
-	return nil
+	return helper.Unzip(zipReader, projectName)
 }
 
 // func handlerFiles(projectName, templateName string) error {
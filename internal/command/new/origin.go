@@ -0,0 +1,46 @@
+package new
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spruce1698/kun/config"
+	"gopkg.in/yaml.v3"
+)
+
+// OriginFile 记录项目由哪个模板的哪个版本生成, 用于按相同版本重新生成
+const OriginFile = ".kun/template.yaml"
+
+// origin 生成项目所用的模板
+type origin struct {
+	Layout     string    `yaml:"layout,omitempty"`     // 内置模板
+	Components []string  `yaml:"components,omitempty"` // 内置模板选择的组件, 为空时未选择组件
+	Template   string    `yaml:"template,omitempty"`   // kun template 注册的模板
+	Source     string    `yaml:"source,omitempty"`     // git 地址或本地目录/zip
+	Ref        string    `yaml:"ref,omitempty"`
+	Subdir     string    `yaml:"subdir,omitempty"`
+	Commit     string    `yaml:"commit,omitempty"` // 解析后的 git commit
+	KunVersion string    `yaml:"kunVersion"`
+	CreatedAt  time.Time `yaml:"createdAt"`
+}
+
+// writeOrigin 在项目中记录模板来源
+func (p *Project) writeOrigin() error {
+	o := p.Origin
+	if p.Template != "" {
+		o.Layout = p.Template
+		o.Components = p.Components
+	}
+	o.KunVersion = config.Version
+	o.CreatedAt = time.Now()
+	data, err := yaml.Marshal(&o)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(p.Dir, filepath.FromSlash(OriginFile))
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte("# generated by kun new, do not edit\n"), data...), 0o644)
+}
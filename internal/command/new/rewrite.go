@@ -0,0 +1,154 @@
+package new
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+const (
+	// 替换内容中的占位
+	rewriteOld    = "{old}"    // 模板原 module 名, 仅用于 Pattern
+	rewriteModule = "{module}" // 新项目的 module 路径
+	rewriteName   = "{name}"   // 新项目的目录名
+
+	// 替换过程中的哨兵, 防止新 module 名中含有旧名时被后续规则再次替换
+	sentinelModule = "\x00kun:module\x00"
+	sentinelName   = "\x00kun:name\x00"
+
+	// 检测二进制文件时读取的字节数
+	binarySniffLen = 8000
+)
+
+// rewriteRule 模板改名规则: 在匹配 Files 的文本文件中, 把 Pattern 替换为 Replace
+type rewriteRule struct {
+	Files   []string `yaml:"files"`   // 相对项目根目录的路径匹配, 支持 * 和 **, 为空时匹配全部文本文件
+	Pattern string   `yaml:"pattern"` // 正则, 支持 {old} 占位
+	Replace string   `yaml:"replace"` // 替换内容, 支持 {module} {name} 占位及正则分组 $1
+}
+
+// 未声明改名规则的模板(如 git 模板): 全部文本文件中的旧 module 名替换为新 module 路径
+var defaultRewrites = []rewriteRule{
+	{Pattern: rewriteOld, Replace: rewriteModule},
+}
+
+// 内置模板的改名规则: import 路径使用 module, 配置/部署/文档中的名称使用项目目录名
+var templateRewrites = map[string][]rewriteRule{
+	LayoutAdvanced: {
+		{Files: []string{"**/*.go"}, Pattern: `"{old}(/|")`, Replace: `"{module}$1`},
+		{
+			Files: []string{
+				"config/*.yml",
+				"deploy/**",
+				"docs/swagger/*",
+				"cmd/*/main.go",
+				"Makefile",
+				"README.md",
+				"*.sql",
+			},
+			Pattern: `\b{old}\b`,
+			Replace: rewriteName,
+		},
+	},
+	LayoutBasic: {
+		{Files: []string{"**/*.go"}, Pattern: `"{old}(/|")`, Replace: `"{module}$1`},
+		{
+			Files: []string{
+				"config/*.yml",
+				"cmd/*/main.go",
+				"README.md",
+				"*.sql",
+			},
+			Pattern: `\b{old}\b`,
+			Replace: rewriteName,
+		},
+	},
+}
+
+// compiledRule 编译后的改名规则
+type compiledRule struct {
+	files   []string
+	pattern *regexp.Regexp
+	replace string
+}
+
+func compileRewrites(rules []rewriteRule, old string) ([]compiledRule, error) {
+	result := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		pattern := strings.ReplaceAll(r.Pattern, rewriteOld, regexp.QuoteMeta(old))
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite pattern %q: %w", r.Pattern, err)
+		}
+		replace := strings.NewReplacer(rewriteModule, sentinelModule, rewriteName, sentinelName).Replace(r.Replace)
+		result = append(result, compiledRule{files: r.Files, pattern: re, replace: replace})
+	}
+	return result, nil
+}
+
+func (r compiledRule) match(rel string) bool {
+	if len(r.files) == 0 {
+		return true
+	}
+	for _, f := range r.files {
+		if helper.MatchPath(f, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary 前 binarySniffLen 个字节内包含 NUL 视为二进制文件
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// rewriteFiles 按规则改写项目中的全部文本文件, 跳过 .git 目录和二进制文件
+func rewriteFiles(root string, rules []compiledRule, modulePath, name string) error {
+	values := strings.NewReplacer(sentinelModule, modulePath, sentinelName, name)
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if isBinary(data) {
+			return nil
+		}
+		newData := data
+		for _, r := range rules {
+			if r.match(rel) {
+				newData = r.pattern.ReplaceAll(newData, []byte(r.replace))
+			}
+		}
+		if bytes.Equal(newData, data) {
+			return nil
+		}
+		return os.WriteFile(path, []byte(values.Replace(string(newData))), info.Mode().Perm())
+	})
+}
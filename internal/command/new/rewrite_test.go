@@ -0,0 +1,111 @@
+package new
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewriteFiles(t *testing.T) {
+	const old = "kun-layout"
+	tests := []struct {
+		name       string
+		rules      []rewriteRule
+		modulePath string
+		files      map[string]string // 相对路径 -> 内容
+		want       map[string]string
+	}{
+		{
+			name:       "default rules replace everywhere",
+			rules:      defaultRewrites,
+			modulePath: "github.com/a/shop",
+			files: map[string]string{
+				"go.mod":       "module kun-layout\n",
+				"main.go":      "import \"kun-layout/internal\"\n",
+				"config/a.yml": "name: kun-layout\n",
+			},
+			want: map[string]string{
+				"go.mod":       "module github.com/a/shop\n",
+				"main.go":      "import \"github.com/a/shop/internal\"\n",
+				"config/a.yml": "name: github.com/a/shop\n",
+			},
+		},
+		{
+			name:       "advanced layout uses module in imports and name in config",
+			rules:      templateRewrites[LayoutAdvanced],
+			modulePath: "github.com/a/shop",
+			files: map[string]string{
+				"internal/a.go":        "import (\n\t\"kun-layout\"\n\t\"kun-layout/pkg\"\n)\n// kun-layout-x\n",
+				"config/local.yml":     "app: kun-layout\n",
+				"cmd/server/main.go":   "import \"kun-layout/cmd\"\n// kun-layout server\n",
+				"docs/other/readme.md": "kun-layout\n",
+			},
+			want: map[string]string{
+				"internal/a.go":        "import (\n\t\"github.com/a/shop\"\n\t\"github.com/a/shop/pkg\"\n)\n// kun-layout-x\n",
+				"config/local.yml":     "app: shop\n",
+				"cmd/server/main.go":   "import \"github.com/a/shop/cmd\"\n// shop server\n",
+				"docs/other/readme.md": "kun-layout\n",
+			},
+		},
+		{
+			// 新 module 名包含旧名时不会被后续规则再次替换
+			name: "new module contains old name",
+			rules: []rewriteRule{
+				{Pattern: rewriteOld, Replace: rewriteModule},
+				{Pattern: rewriteOld, Replace: rewriteName},
+			},
+			modulePath: "github.com/a/kun-layout-v2",
+			files:      map[string]string{"go.mod": "module kun-layout\n"},
+			want:       map[string]string{"go.mod": "module github.com/a/kun-layout-v2\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rules, err := compileRewrites(tt.rules, old)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = rewriteFiles(root, rules, tt.modulePath, filepath.Base(tt.modulePath)); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.want {
+				got, err := os.ReadFile(filepath.Join(root, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteFilesSkipsBinary(t *testing.T) {
+	root := t.TempDir()
+	data := []byte("kun-layout\x00kun-layout")
+	path := filepath.Join(root, "logo.png")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := compileRewrites(defaultRewrites, "kun-layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rewriteFiles(root, rules, "shop", "shop"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(data) {
+		t.Errorf("binary file rewritten: %q", got)
+	}
+}
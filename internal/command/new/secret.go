@@ -0,0 +1,152 @@
+package new
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+const secretLength = 32
+
+// secretRule 生成项目时替换为随机值的密钥: 在匹配 Files 的文件中, 把 Pattern 第一个分组的内容替换为随机值.
+// Name 相同的规则使用同一个随机值, config 目录下的 yml 文件会另外生成以 <Name> 为占位的 xxx.example.yml
+type secretRule struct {
+	Name    string   `yaml:"name"`
+	Files   []string `yaml:"files"`
+	Pattern string   `yaml:"pattern"`
+	Length  int      `yaml:"length"` // 默认 32
+}
+
+// 内置模板中固定的密钥和密码
+var templateSecrets = map[string][]secretRule{
+	LayoutAdvanced: {
+		{Name: "token-secret", Files: []string{"config/*.yml"}, Pattern: `(?m)^\s+secret:\s*"([^"]*)"`},
+		{Name: "token-refresh-secret", Files: []string{"config/*.yml"}, Pattern: `(?m)^\s+refreshSecret:\s*"([^"]*)"`},
+		{Name: "token-default-secret", Files: []string{"pkg/token/jwt.go"}, Pattern: `DefaultSecret\s*= "([^"]*)"`},
+		{Name: "token-default-refresh-secret", Files: []string{"pkg/token/jwt.go"}, Pattern: `DefaultRefreshSecret\s*= "([^"]*)"`},
+		{Name: "mysql-password", Files: []string{"config/*.yml"}, Pattern: `root:([^@"]*)@tcp`, Length: 16},
+		{Name: "mysql-password", Files: []string{"deploy/**/docker-compose.yml"}, Pattern: `MYSQL_ROOT_PASSWORD: (\S+)`, Length: 16},
+		{Name: "redis-password", Files: []string{"config/*.yml"}, Pattern: `(?m)^\s+password:\s*"([^"]*)"`, Length: 16},
+		{Name: "redis-password", Files: []string{"deploy/**/docker-compose.yml"}, Pattern: `--requirepass (\S+)`, Length: 16},
+	},
+	LayoutBasic: {
+		{Name: "token-secret", Files: []string{"config/*.yml"}, Pattern: `(?m)^\s+secret:\s*"([^"]*)"`},
+		{Name: "token-refresh-secret", Files: []string{"config/*.yml"}, Pattern: `(?m)^\s+refreshSecret:\s*"([^"]*)"`},
+		{Name: "token-default-secret", Files: []string{"pkg/token/jwt.go"}, Pattern: `DefaultSecret\s*= "([^"]*)"`},
+		{Name: "token-default-refresh-secret", Files: []string{"pkg/token/jwt.go"}, Pattern: `DefaultRefreshSecret\s*= "([^"]*)"`},
+		{Name: "mysql-password", Files: []string{"config/*.yml"}, Pattern: `root:([^@"]*)@tcp`, Length: 16},
+		{Name: "redis-password", Files: []string{"config/*.yml"}, Pattern: `(?m)^\s+password:\s*"([^"]*)"`, Length: 16},
+	},
+}
+
+// generateSecrets 把模板中固定的密钥替换为随机值, 并生成带占位的配置示例
+func (p *Project) generateSecrets() error {
+	rules := templateSecrets[p.Template]
+	if p.manifest != nil && len(p.manifest.Secrets) > 0 {
+		rules = p.manifest.Secrets
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	patterns := make([]*regexp.Regexp, len(rules))
+	values := make(map[string]string)
+	for i, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid secret pattern %q: %w", r.Pattern, err)
+		}
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("secret pattern %q needs a group for the value", r.Pattern)
+		}
+		patterns[i] = re
+		if _, ok := values[r.Name]; !ok {
+			length := r.Length
+			if length <= 0 {
+				length = secretLength
+			}
+			if values[r.Name], err = helper.RandomString(length); err != nil {
+				return err
+			}
+		}
+	}
+	placeholders := make(map[string]string, len(values))
+	for name := range values {
+		placeholders[name] = "<" + name + ">"
+	}
+
+	var replaced []string
+	err := filepath.Walk(p.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(p.Dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		var matched []int
+		for i, r := range rules {
+			for _, f := range r.Files {
+				if helper.MatchPath(f, rel) {
+					matched = append(matched, i)
+					break
+				}
+			}
+		}
+		if len(matched) == 0 {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content := string(data)
+		secrets := replaceSecrets(content, rules, patterns, matched, values)
+		if secrets == content {
+			return nil
+		}
+		replaced = append(replaced, rel)
+		if err = os.WriteFile(path, []byte(secrets), info.Mode().Perm()); err != nil {
+			return err
+		}
+		// config 下的 yml 生成带占位的示例, 可以提交到仓库
+		ext := filepath.Ext(rel)
+		if strings.HasPrefix(rel, "config/") && (ext == ".yml" || ext == ".yaml") && !strings.HasSuffix(rel, ".example"+ext) {
+			example := replaceSecrets(content, rules, patterns, matched, placeholders)
+			return os.WriteFile(strings.TrimSuffix(path, ext)+".example"+ext, []byte(example), info.Mode().Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(replaced) > 0 {
+		fmt.Success("Generate random secrets in %s", strings.Join(replaced, ", "))
+	}
+	return nil
+}
+
+// replaceSecrets 把匹配规则的第一个分组替换为对应的值
+func replaceSecrets(content string, rules []secretRule, patterns []*regexp.Regexp, matched []int, values map[string]string) string {
+	for _, i := range matched {
+		value := values[rules[i].Name]
+		var b strings.Builder
+		last := 0
+		for _, loc := range patterns[i].FindAllStringSubmatchIndex(content, -1) {
+			if loc[2] < 0 {
+				continue
+			}
+			b.WriteString(content[last:loc[2]])
+			b.WriteString(value)
+			last = loc[3]
+		}
+		b.WriteString(content[last:])
+		content = b.String()
+	}
+	return content
+}
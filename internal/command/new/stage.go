@@ -0,0 +1,105 @@
+package new
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+)
+
+// step 生成项目的步骤
+type step struct {
+	Name string
+	Run  func() error
+}
+
+// stepError 失败的步骤
+type stepError struct {
+	Step string
+	Err  error
+}
+
+func (e *stepError) Error() string {
+	return fmt.Sprintf("step [%s] failed: %s", e.Step, e.Err)
+}
+
+func (e *stepError) Unwrap() error {
+	return e.Err
+}
+
+// stage 在项目目录的同级创建临时目录, 所有步骤都在临时目录中进行
+func (p *Project) stage() error {
+	target, err := filepath.Abs(p.ProjectName)
+	if err != nil {
+		return err
+	}
+	parent := filepath.Dir(target)
+	if err = os.MkdirAll(parent, 0o755); err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp(parent, "."+filepath.Base(target)+".kun-")
+	if err != nil {
+		return err
+	}
+	p.Dir = dir
+	// MkdirTemp 创建的目录权限为 0700
+	return os.Chmod(dir, 0o755)
+}
+
+// cleanup 删除临时目录, 已移动到项目目录时不做任何事
+func (p *Project) cleanup() {
+	if p.Dir != "" {
+		_ = os.RemoveAll(p.Dir)
+	}
+}
+
+// moveIntoPlace 所有步骤成功后用临时目录替换项目目录, 旧目录在替换成功后才删除
+func (p *Project) moveIntoPlace() error {
+	var backup string
+	if _, err := os.Lstat(p.ProjectName); err == nil {
+		backup = p.Dir + ".old"
+		if err = os.Rename(p.ProjectName, backup); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(p.Dir, p.ProjectName); err != nil {
+		if backup != "" {
+			_ = os.Rename(backup, p.ProjectName)
+		}
+		return err
+	}
+	p.Dir = ""
+	if backup != "" {
+		if err := os.RemoveAll(backup); err != nil {
+			fmt.Warn("remove old project %s error: %s", backup, err)
+		}
+	}
+	return nil
+}
+
+// fail 生成失败时临时目录由 cleanup 删除, 已存在的项目目录保持不变
+func (p *Project) fail(name string, err error) error {
+	if p.Dir != "" {
+		fmt.Warn("Project [ %s ] was not created, nothing was changed.", p.ProjectName)
+	}
+	var se *stepError
+	if errors.As(err, &se) {
+		return err
+	}
+	return &stepError{Step: name, Err: err}
+}
+
+// command 在临时目录中执行命令, 失败时返回命令及其输出
+func (p *Project) command(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = p.Dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w\n%s", name, strings.Join(args, " "), err, bytes.TrimSpace(out))
+	}
+	return nil
+}
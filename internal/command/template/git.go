@@ -0,0 +1,69 @@
+package template
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+// Clone 克隆 git 模板并检出 ref(分支/tag/commit, 为空时使用默认分支),
+// 把 subdir(为空时为仓库根目录)复制到 dst, 返回检出的 commit
+func Clone(source, ref, subdir, dst string) (string, error) {
+	if subdir != "" && !filepath.IsLocal(subdir) {
+		return "", fmt.Errorf("invalid subdir %q, must be a relative path inside the repository", subdir)
+	}
+	tmp, err := os.MkdirTemp("", "kun-clone-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+
+	if _, err = git("", "clone", "--quiet", source, tmp); err != nil {
+		return "", err
+	}
+	commit, err := resolveRef(tmp, ref)
+	if err != nil {
+		return "", err
+	}
+	if _, err = git(tmp, "checkout", "--quiet", "--detach", commit); err != nil {
+		return "", err
+	}
+
+	root := filepath.Join(tmp, subdir)
+	if stat, err := os.Stat(root); err != nil || !stat.IsDir() {
+		return "", fmt.Errorf("subdir %s not found in %s@%s", subdir, source, commit)
+	}
+	if err = helper.CopyDir(root, dst); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// resolveRef 把分支/tag/commit 解析为 commit, 分支优先使用远端的最新提交
+func resolveRef(dir, ref string) (string, error) {
+	if ref == "" {
+		return git(dir, "rev-parse", "HEAD")
+	}
+	for _, name := range []string{"origin/" + ref, ref} {
+		if commit, err := git(dir, "rev-parse", "--verify", "--quiet", name+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("ref %s not found", ref)
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s error: %w\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,268 @@
+package template
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SourceGit = "git"
+	SourceDir = "dir"
+	SourceZip = "zip"
+	SourceTgz = "tgz"
+
+	registryFile = "registry.yaml"
+)
+
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Template 注册的模板, 缓存在用户配置目录 kun/templates/<name> 下
+type Template struct {
+	Name      string    `yaml:"name"`
+	Type      string    `yaml:"type"`             // git | dir | zip | tgz
+	Source    string    `yaml:"source"`           // git 地址或本地目录/压缩包的绝对路径
+	Ref       string    `yaml:"ref,omitempty"`    // git 固定的分支/tag/commit, 为空时使用默认分支
+	Subdir    string    `yaml:"subdir,omitempty"` // git 仓库中模板所在的子目录
+	Commit    string    `yaml:"commit,omitempty"` // git 缓存对应的 commit
+	UpdatedAt time.Time `yaml:"updatedAt"`
+	dir       string
+}
+
+// Dir 模板缓存目录
+func (t *Template) Dir() string {
+	return t.dir
+}
+
+// Version 模板版本描述
+func (t *Template) Version() string {
+	if t.Commit == "" {
+		return t.UpdatedAt.Format(time.DateTime)
+	}
+	commit := t.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if t.Ref == "" {
+		return commit
+	}
+	return t.Ref + "@" + commit
+}
+
+// registry 本地模板仓库
+type registry struct {
+	Templates []*Template `yaml:"templates"`
+	root      string
+}
+
+// registryRoot 模板缓存根目录
+func registryRoot() (string, error) {
+	dir, err := helper.ConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the user config dir: %w", err)
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+func loadRegistry() (*registry, error) {
+	root, err := registryRoot()
+	if err != nil {
+		return nil, err
+	}
+	r := &registry{root: root}
+	data, err := os.ReadFile(filepath.Join(root, registryFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", filepath.Join(root, registryFile), err)
+	}
+	for _, t := range r.Templates {
+		t.dir = filepath.Join(root, t.Name)
+	}
+	return r, nil
+}
+
+func (r *registry) save() error {
+	if err := os.MkdirAll(r.root, 0o755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再改名, 避免中断时损坏仓库文件
+	tmp := filepath.Join(r.root, registryFile+".tmp")
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(r.root, registryFile))
+}
+
+func (r *registry) get(name string) *Template {
+	for _, t := range r.Templates {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// put 新增或替换模板
+func (r *registry) put(t *Template) {
+	for i, old := range r.Templates {
+		if old.Name == t.Name {
+			r.Templates[i] = t
+			return
+		}
+	}
+	r.Templates = append(r.Templates, t)
+	slices.SortFunc(r.Templates, func(a, b *Template) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+func (r *registry) remove(name string) error {
+	r.Templates = slices.DeleteFunc(r.Templates, func(t *Template) bool {
+		return t.Name == name
+	})
+	return os.RemoveAll(filepath.Join(r.root, name))
+}
+
+// Lookup 查找已注册的模板, 供 kun new --template 使用
+func Lookup(name string) (*Template, error) {
+	r, err := loadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	t := r.get(name)
+	if t == nil {
+		return nil, fmt.Errorf("template %s is not registered, see kun template list", name)
+	}
+	if _, err = os.Stat(t.dir); err != nil {
+		return nil, fmt.Errorf("template %s cache is broken, run kun template update %s: %w", name, name, err)
+	}
+	return t, nil
+}
+
+// detectSource 本地目录/zip 文件之外的来源均视为 git 地址
+func detectSource(source string) (string, string, error) {
+	stat, err := os.Stat(source)
+	if err != nil {
+		return SourceGit, source, nil
+	}
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return "", "", err
+	}
+	if stat.IsDir() {
+		return SourceDir, abs, nil
+	}
+	lower := strings.ToLower(source)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return SourceZip, abs, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return SourceTgz, abs, nil
+	}
+	return "", "", fmt.Errorf("unsupported template source %s, must be a git url, a directory, a .zip or a .tar.gz file", source)
+}
+
+// fetch 把模板拉取到缓存目录, 先写入临时目录, 成功后替换旧缓存
+func (r *registry) fetch(t *Template) error {
+	if err := os.MkdirAll(r.root, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(r.root, "."+t.Name+"-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+
+	dst := filepath.Join(tmp, t.Name)
+	switch t.Type {
+	case SourceGit:
+		t.Commit, err = Clone(t.Source, t.Ref, t.Subdir, dst)
+	case SourceDir:
+		err = helper.CopyDir(t.Source, dst)
+	case SourceZip, SourceTgz:
+		err = fetchArchive(t.Type, t.Source, dst)
+	default:
+		err = fmt.Errorf("unknown template type %q", t.Type)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stat(filepath.Join(dst, "go.mod")); err != nil {
+		return fmt.Errorf("template %s has no go.mod in its root", t.Source)
+	}
+
+	t.dir = filepath.Join(r.root, t.Name)
+	if err = os.RemoveAll(t.dir); err != nil {
+		return err
+	}
+	if err = os.Rename(dst, t.dir); err != nil {
+		return err
+	}
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// fetchArchive 解压 zip/tar.gz, 只有一个顶层目录时(如 GitHub 下载的源码包)以该目录为模板根目录
+func fetchArchive(typ, source, dst string) error {
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	tmp := dst + ".extract"
+	if err = os.MkdirAll(tmp, 0o755); err != nil {
+		return err
+	}
+	if typ == SourceTgz {
+		err = helper.Untar(f, tmp)
+	} else {
+		err = unzip(f, tmp)
+	}
+	if err != nil {
+		return fmt.Errorf("extract %s error: %w", source, err)
+	}
+
+	root := tmp
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(tmp, entries[0].Name())
+	}
+	return os.Rename(root, dst)
+}
+
+func unzip(f *os.File, dst string) error {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	zipReader, err := zip.NewReader(f, stat.Size())
+	if err != nil {
+		return err
+	}
+	return helper.Unzip(zipReader, dst)
+}
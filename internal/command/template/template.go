@@ -0,0 +1,188 @@
+package template
+
+import (
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/pkg/fmt"
+)
+
+var (
+	ref    string
+	subdir string
+	force  bool
+
+	CmdTemplate = &cobra.Command{
+		Use:     "template",
+		Short:   "Manage local templates for kun new",
+		Example: "kun template add mylayout https://github.com/foo/layout.git --ref v1.0.0\n  kun new demo --template mylayout",
+	}
+
+	CmdTemplateAdd = &cobra.Command{
+		Use:          "add [name] [git url|dir|zip|tar.gz]",
+		Short:        "Register a template from a git url, a local directory or a zip/tar.gz archive",
+		Example:      "kun template add mylayout https://github.com/foo/layout.git --ref v1.0.0\n  kun template add api https://github.com/foo/layouts.git --subdir api\n  kun template add local ./layout\n  kun template add zipped ./layout.zip\n  kun template add tarball ./layout.tar.gz",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE:         runAdd,
+	}
+
+	CmdTemplateList = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List registered templates",
+		Example: "kun template list",
+		Args:    cobra.NoArgs,
+		RunE:    runList,
+	}
+
+	CmdTemplateRemove = &cobra.Command{
+		Use:          "remove [name...]",
+		Aliases:      []string{"rm"},
+		Short:        "Remove registered templates and their cache",
+		Example:      "kun template remove mylayout",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE:         runRemove,
+	}
+
+	CmdTemplateUpdate = &cobra.Command{
+		Use:          "update [name...]",
+		Short:        "Refresh the cache of registered templates, default all",
+		Example:      "kun template update mylayout --ref v1.1.0\n  kun template update",
+		SilenceUsage: true,
+		RunE:         runUpdate,
+	}
+)
+
+func init() {
+	CmdTemplateAdd.Flags().StringVarP(&ref, "ref", "r", ref, "git branch, tag or commit to pin")
+	CmdTemplateAdd.Flags().StringVar(&subdir, "subdir", subdir, "directory of the template inside the git repository")
+	CmdTemplateAdd.Flags().BoolVarP(&force, "force", "f", force, "replace the template if it already exists")
+	CmdTemplateUpdate.Flags().StringVarP(&ref, "ref", "r", ref, "pin the git template to another branch, tag or commit")
+}
+
+func runAdd(_ *cobra.Command, args []string) error {
+	name, source := args[0], args[1]
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid template name %q, must match %s", name, nameRegexp)
+	}
+	r, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	if r.get(name) != nil && !force {
+		return fmt.Errorf("template %s already exists, use --force to replace it or kun template update %s", name, name)
+	}
+	typ, source, err := detectSource(source)
+	if err != nil {
+		return err
+	}
+	if (ref != "" || subdir != "") && typ != SourceGit {
+		return fmt.Errorf("--ref and --subdir can only be used with git templates")
+	}
+
+	t := &Template{Name: name, Type: typ, Source: source, Ref: ref, Subdir: subdir}
+	fmt.Success("Fetch template %s from %s", name, source)
+	if err = r.fetch(t); err != nil {
+		return fmt.Errorf("fetch template %s error: %w", name, err)
+	}
+	r.put(t)
+	if err = r.save(); err != nil {
+		return err
+	}
+	fmt.Success("Template [ %s ] added, version %s", name, t.Version())
+	fmt.Success("› kun new demo --template %s", name)
+	return nil
+}
+
+func runList(_ *cobra.Command, _ []string) error {
+	r, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	if len(r.Templates) == 0 {
+		fmt.Warn("No templates, add one with: kun template add [name] [git url|dir|zip|tar.gz]")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tTYPE\tVERSION\tUPDATED\tSOURCE")
+	for _, t := range r.Templates {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", t.Name, t.Type, t.Version(), t.UpdatedAt.Format("2006-01-02 15:04"), t.Source)
+	}
+	return w.Flush()
+}
+
+func runRemove(_ *cobra.Command, args []string) error {
+	r, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	for _, name := range args {
+		if r.get(name) == nil {
+			return fmt.Errorf("template %s is not registered", name)
+		}
+	}
+	for _, name := range args {
+		if err = r.remove(name); err != nil {
+			return err
+		}
+		fmt.Success("Template [ %s ] removed", name)
+	}
+	return r.save()
+}
+
+func runUpdate(_ *cobra.Command, args []string) error {
+	r, err := loadRegistry()
+	if err != nil {
+		return err
+	}
+	if ref != "" && len(args) != 1 {
+		return fmt.Errorf("--ref needs exactly one template name")
+	}
+	templates := r.Templates
+	if len(args) > 0 {
+		templates = templates[:0:0]
+		for _, name := range args {
+			t := r.get(name)
+			if t == nil {
+				return fmt.Errorf("template %s is not registered", name)
+			}
+			templates = append(templates, t)
+		}
+	}
+	if len(templates) == 0 {
+		fmt.Warn("No templates to update")
+		return nil
+	}
+
+	var failed int
+	for _, old := range templates {
+		t := *old
+		if ref != "" {
+			if t.Type != SourceGit {
+				return fmt.Errorf("--ref can only be used with git templates")
+			}
+			t.Ref = ref
+		}
+		if err = r.fetch(&t); err != nil {
+			failed++
+			fmt.Error("update template %s error: %s", t.Name, err)
+			continue
+		}
+		r.put(&t)
+		if old.Commit != "" && old.Commit != t.Commit {
+			fmt.Success("Template [ %s ] updated: %s → %s", t.Name, old.Version(), t.Version())
+		} else {
+			fmt.Success("Template [ %s ] updated, version %s", t.Name, t.Version())
+		}
+	}
+	if err = r.save(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d template(s) failed to update", failed)
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package token
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v5 "github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/cobra"
+	"github.com/spruce1698/kun/pkg/fmt"
+	"github.com/spruce1698/kun/pkg/helper"
+)
+
+// 与模板 pkg/token 保持一致
+const (
+	TokenTypeAccess  = "somebody"
+	TokenTypeRefresh = "refresh"
+
+	defaultExpire  = 2 * time.Hour
+	defaultRefresh = 24 * 7 * time.Hour
+	jwtFile        = "pkg/token/jwt.go"
+)
+
+var (
+	uid      int64
+	env      string
+	config   string
+	expire   time.Duration
+	quiet    bool
+	noVerify bool
+
+	CmdToken = &cobra.Command{
+		Use:     "token",
+		Short:   "Issue and decode development tokens with the project config",
+		Example: "kun token issue --uid 123 --env local\n  kun token decode <token>",
+	}
+
+	CmdTokenIssue = &cobra.Command{
+		Use:          "issue",
+		Short:        "Sign an access token and a refresh token like pkg/token.Jwt.Gen",
+		Example:      "kun token issue --uid 123 --env local\n  curl -H \"Authorization: $(kun token issue --uid 123 -q)\" localhost:8000/v1/user",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         runIssue,
+	}
+
+	CmdTokenDecode = &cobra.Command{
+		Use:          "decode [token]",
+		Short:        "Print the claims of a token and validate it like pkg/token.Jwt.Parse",
+		Example:      "kun token decode eyJhbGciOiJIUzM4NCIs... --env local\n  kun token decode \"Bearer eyJhbGciOiJIUzM4NCIs...\" --no-verify",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE:         runDecode,
+	}
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{CmdTokenIssue, CmdTokenDecode} {
+		cmd.Flags().StringVarP(&env, "env", "e", "local", "read the token config from config/<env>.yml")
+		cmd.Flags().StringVarP(&config, "config", "c", config, "config file, overrides --env")
+	}
+	CmdTokenIssue.Flags().Int64VarP(&uid, "uid", "u", uid, "user id, the subject of the token")
+	CmdTokenIssue.Flags().DurationVar(&expire, "expire", expire, "override the access token expire time, e.g. 30m")
+	CmdTokenIssue.Flags().BoolVarP(&quiet, "quiet", "q", quiet, "only print the access token")
+	_ = CmdTokenIssue.MarkFlagRequired("uid")
+	CmdTokenDecode.Flags().BoolVar(&noVerify, "no-verify", noVerify, "only decode, do not check the signature and claims")
+}
+
+// tokenConf 项目配置中的 token 部分, 未配置的值与 NewJwt 的默认值一致
+type tokenConf struct {
+	path                  string
+	Secret                string
+	RefreshSecret         string
+	PreviousSecret        string
+	PreviousRefreshSecret string
+	Expire                time.Duration
+	Refresh               time.Duration
+}
+
+// loadConf 读取配置文件, 与 xconfig 一样支持 CONF 和 TOKEN_SECRET 等环境变量
+func loadConf() (*tokenConf, error) {
+	path := config
+	if path == "" {
+		path = os.Getenv("CONF")
+	}
+	if path == "" {
+		path = filepath.Join("config", env+".yml")
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("config file %s not found, run it in the project root or use --config", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := helper.ParseYaml(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s error: %w", path, err)
+	}
+	value := func(key string) string {
+		if v, ok := os.LookupEnv(strings.ToUpper(strings.ReplaceAll(key, ".", "_"))); ok {
+			return v
+		}
+		if node := f.Lookup(key); node != nil {
+			return node.Value
+		}
+		return ""
+	}
+	seconds := func(key string, def time.Duration) (time.Duration, error) {
+		v := value(key)
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s in %s must be seconds: %w", key, path, err)
+		}
+		if n <= 0 {
+			return def, nil
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+
+	conf := &tokenConf{
+		path:                  path,
+		Secret:                value("token.secret"),
+		RefreshSecret:         value("token.refreshSecret"),
+		PreviousSecret:        value("token.previous.secret"),
+		PreviousRefreshSecret: value("token.previous.refreshSecret"),
+	}
+	// kun config encrypt 加密的密钥
+	for _, secret := range []*string{&conf.Secret, &conf.RefreshSecret, &conf.PreviousSecret, &conf.PreviousRefreshSecret} {
+		if !helper.IsEncrypted(*secret) {
+			continue
+		}
+		key, err := helper.ConfKey(helper.ConfKeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		if *secret, err = helper.DecryptValue(*secret, key); err != nil {
+			return nil, fmt.Errorf("decrypt token secret in %s error: %w", path, err)
+		}
+	}
+	if conf.Expire, err = seconds("token.expire", defaultExpire); err != nil {
+		return nil, err
+	}
+	if conf.Refresh, err = seconds("token.refresh", defaultRefresh); err != nil {
+		return nil, err
+	}
+	if conf.Secret == "" {
+		conf.Secret = defaultSecret("DefaultSecret")
+	}
+	if conf.RefreshSecret == "" {
+		conf.RefreshSecret = defaultSecret("DefaultRefreshSecret")
+	}
+	if conf.Secret == "" || conf.RefreshSecret == "" {
+		return nil, fmt.Errorf("token.secret and token.refreshSecret are not set in %s", path)
+	}
+	return conf, nil
+}
+
+// defaultSecret 配置中没有密钥时 NewJwt 使用 pkg/token 中的默认值
+func defaultSecret(name string) string {
+	data, err := os.ReadFile(jwtFile)
+	if err != nil {
+		return ""
+	}
+	m := regexp.MustCompile(name + `\s*= "([^"]*)"`).FindSubmatch(data)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+func runIssue(_ *cobra.Command, _ []string) error {
+	conf, err := loadConf()
+	if err != nil {
+		return err
+	}
+	if expire > 0 {
+		conf.Expire = expire
+	}
+
+	cTime := time.Now()
+	id, err := helper.RandomString(16)
+	if err != nil {
+		return err
+	}
+	claims := v5.RegisteredClaims{
+		IssuedAt:  v5.NewNumericDate(cTime),
+		NotBefore: v5.NewNumericDate(cTime.Add(-1 * time.Second)),
+		ExpiresAt: v5.NewNumericDate(cTime.Add(conf.Expire)),
+		Issuer:    TokenTypeAccess,
+		Subject:   strconv.FormatInt(uid, 10),
+		ID:        id,
+	}
+	accessToken, err := v5.NewWithClaims(v5.SigningMethodHS384, claims).SignedString([]byte(conf.Secret))
+	if err != nil {
+		return err
+	}
+	refreshClaims := v5.RegisteredClaims{
+		IssuedAt:  claims.IssuedAt,
+		NotBefore: claims.NotBefore,
+		ExpiresAt: v5.NewNumericDate(cTime.Add(conf.Refresh)),
+		Issuer:    TokenTypeRefresh,
+		ID:        md5Hex16(claims.ID + claims.Subject + claims.IssuedAt.String()),
+	}
+	refreshToken, err := v5.NewWithClaims(v5.SigningMethodHS384, refreshClaims).SignedString([]byte(conf.RefreshSecret))
+	if err != nil {
+		return err
+	}
+
+	if quiet {
+		_, err = fmt.Fprintln(os.Stdout, accessToken)
+		return err
+	}
+	fmt.Success("Sign tokens for uid %d with %s", uid, conf.path)
+	fmt.Print("AccessToken:     %s", accessToken)
+	fmt.Print("AccessExpireAt:  %s", claims.ExpiresAt.Local().Format(time.DateTime))
+	fmt.Print("RefreshToken:    %s", refreshToken)
+	fmt.Print("RefreshExpireAt: %s", refreshClaims.ExpiresAt.Local().Format(time.DateTime))
+	return nil
+}
+
+func runDecode(_ *cobra.Command, args []string) error {
+	tokenStr := strings.TrimSpace(regexp.MustCompile(`(?i)Bearer `).ReplaceAllString(args[0], ""))
+	parts := strings.Split(tokenStr, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("token must have 3 parts separated by '.'")
+	}
+	for i, name := range []string{"Header", "Claims"} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return fmt.Errorf("decode %s error: %w", strings.ToLower(name), err)
+		}
+		var v map[string]any
+		if err = json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("decode %s error: %w", strings.ToLower(name), err)
+		}
+		out, _ := json.MarshalIndent(v, "", "  ")
+		fmt.Print("%s: %s", name, out)
+	}
+	if noVerify {
+		fmt.Warn("The signature and claims are not verified")
+		return nil
+	}
+
+	conf, err := loadConf()
+	if err != nil {
+		return err
+	}
+	payload, err := parse(conf, tokenStr)
+	if err != nil {
+		return err
+	}
+	for _, t := range []struct {
+		name string
+		time *v5.NumericDate
+	}{{"IssuedAt", payload.IssuedAt}, {"NotBefore", payload.NotBefore}, {"ExpiresAt", payload.ExpiresAt}} {
+		if t.time != nil {
+			fmt.Print("%s: %s", t.name, t.time.Local().Format(time.DateTime))
+		}
+	}
+	if payload.Issuer == TokenTypeAccess {
+		fmt.Success("Valid access token for uid %s, expires in %s", payload.Subject, time.Until(payload.ExpiresAt.Time).Round(time.Second))
+	} else {
+		fmt.Success("Valid refresh token, expires in %s", time.Until(payload.ExpiresAt.Time).Round(time.Second))
+	}
+	return nil
+}
+
+// parse 与 pkg/token.Jwt.Parse 的校验一致, 按签发人选择密钥, 不检查 redis 黑名单
+func parse(conf *tokenConf, tokenStr string) (*v5.RegisteredClaims, error) {
+	unverified := &v5.RegisteredClaims{}
+	if _, _, err := v5.NewParser().ParseUnverified(tokenStr, unverified); err != nil {
+		return nil, fmt.Errorf("token is invalid: %w", err)
+	}
+	secret, previous := conf.Secret, conf.PreviousSecret
+	switch unverified.Issuer {
+	case TokenTypeAccess:
+	case TokenTypeRefresh:
+		secret, previous = conf.RefreshSecret, conf.PreviousRefreshSecret
+	default:
+		return nil, fmt.Errorf("token is invalid: unknown issuer %q", unverified.Issuer)
+	}
+	keys := v5.VerificationKeySet{Keys: []v5.VerificationKey{[]byte(secret)}}
+	if previous != "" && previous != secret {
+		keys.Keys = append(keys.Keys, []byte(previous))
+	}
+
+	payload := &v5.RegisteredClaims{}
+	_, err := v5.ParseWithClaims(tokenStr, payload, func(*v5.Token) (any, error) {
+		return keys, nil
+	}, v5.WithValidMethods([]string{v5.SigningMethodHS384.Alg()}), v5.WithExpirationRequired(), v5.WithIssuedAt())
+	switch {
+	case errors.Is(err, v5.ErrTokenExpired):
+		return nil, fmt.Errorf("token is expired at %s", payload.ExpiresAt.Local().Format(time.DateTime))
+	case errors.Is(err, v5.ErrTokenSignatureInvalid):
+		return nil, fmt.Errorf("token is invalid: the signature does not match the secret in %s", conf.path)
+	case err != nil:
+		return nil, fmt.Errorf("token is invalid: %w", err)
+	}
+	if payload.ID == "" || (payload.Issuer == TokenTypeAccess && payload.Subject == "") {
+		return nil, fmt.Errorf("token is invalid: missing jti or sub")
+	}
+	return payload, nil
+}
+
+// md5Hex16 16位md5
+func md5Hex16(str string) string {
+	hash := md5.Sum([]byte(str))
+	return hex.EncodeToString(hash[:])[8:24]
+}
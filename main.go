@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/spruce1698/kun/cmd/kun"
 	"github.com/spruce1698/kun/pkg/fmt"
 )
@@ -12,6 +14,7 @@ import (
 func main() {
 	err := kun.Execute()
 	if err != nil {
-		fmt.Error("execute error: ", err.Error())
+		fmt.Error("execute error: %s", err.Error())
+		os.Exit(1)
 	}
 }
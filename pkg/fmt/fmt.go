@@ -7,9 +7,11 @@ import (
 )
 
 var (
-	Fscanf  = fmt.Fscanf
-	Sprintf = fmt.Sprintf
-	Errorf  = fmt.Errorf
+	Fscanf   = fmt.Fscanf
+	Sprintf  = fmt.Sprintf
+	Errorf   = fmt.Errorf
+	Fprintf  = fmt.Fprintf
+	Fprintln = fmt.Fprintln
 )
 
 func Green(format string, args ...any) {
@@ -27,3 +29,7 @@ func Error(format string, args ...any) {
 func Warn(format string, args ...any) {
 	color.Yellow(" [!] "+format, args...)
 }
+
+func Print(format string, args ...any) {
+	color.Cyan(" [-] "+format, args...)
+}
@@ -0,0 +1,104 @@
+package helper
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+)
+
+const (
+	// ConfKeyEnv 项目 xconfig 解密 ENC(...) 配置值的密钥所在的环境变量
+	ConfKeyEnv = "CONF_KEY"
+	// encryptedV2 随机 IV 的加密值前缀, 解码后前 16 字节为 IV
+	encryptedV2 = "v2:"
+)
+
+var encryptedRegexp = regexp.MustCompile(`^ENC\((.+)\)$`)
+
+// AESEncrypt AES CBC, PKCS7 填充
+func AESEncrypt(plainText []byte, key string, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	padding := block.BlockSize() - len(plainText)%block.BlockSize()
+	data := append(bytes.Clone(plainText), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return data, nil
+}
+
+// AESDecrypt 解密 AESEncrypt 的结果, 填充不正确时返回错误
+func AESDecrypt(data []byte, key string, iv []byte) (string, error) {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return "", err
+	}
+	size := block.BlockSize()
+	if len(data) == 0 || len(data)%size != 0 {
+		return "", fmt.Errorf("cipherText is not a multiple of the block size")
+	}
+	data = bytes.Clone(data)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(data, data)
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > size || !bytes.Equal(data[len(data)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return "", fmt.Errorf("invalid padding, the key may be wrong")
+	}
+	return string(data[:len(data)-padding]), nil
+}
+
+// ConfKey 从环境变量读取配置加密密钥, 长度必须是 16、24 或 32
+func ConfKey(env string) (string, error) {
+	key := os.Getenv(env)
+	switch len(key) {
+	case 0:
+		return "", fmt.Errorf("set the AES key of the config in env %s", env)
+	case 16, 24, 32:
+		return key, nil
+	}
+	return "", fmt.Errorf("the key in env %s must be 16, 24 or 32 bytes, got %d", env, len(key))
+}
+
+// IsEncrypted 是否为 ENC(...) 形式的加密值
+func IsEncrypted(value string) bool {
+	return encryptedRegexp.MatchString(strings.TrimSpace(value))
+}
+
+// EncryptValue 使用随机 IV 加密为 ENC(v2:...), 相同的明文每次得到不同的密文
+func EncryptValue(value, key string) (string, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	data, err := AESEncrypt([]byte(value), key, iv)
+	if err != nil {
+		return "", err
+	}
+	return "ENC(" + encryptedV2 + base64.StdEncoding.EncodeToString(append(iv, data...)) + ")", nil
+}
+
+// DecryptValue 解密 ENC(v2:...), 其他值原样返回
+func DecryptValue(value, key string) (string, error) {
+	m := encryptedRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return value, nil
+	}
+	text, ok := strings.CutPrefix(m[1], encryptedV2)
+	if !ok {
+		return "", fmt.Errorf("unsupported encrypted value, it must be ENC(%s...)", encryptedV2)
+	}
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aes.BlockSize {
+		return "", fmt.Errorf("cipherText is too short")
+	}
+	return AESDecrypt(data[aes.BlockSize:], key, data[:aes.BlockSize])
+}
@@ -0,0 +1,67 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+)
+
+const testKey = "0123456789abcdef"
+
+func TestEncryptValue(t *testing.T) {
+	for _, value := range []string{"", "s3cret-pass", "0123456789abcdef", "密码 with unicode"} {
+		enc, err := EncryptValue(value, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(enc) || !strings.HasPrefix(enc, "ENC("+encryptedV2) {
+			t.Errorf("EncryptValue(%q) = %q, want ENC(v2:...)", value, enc)
+		}
+		got, err := DecryptValue(enc, testKey)
+		if err != nil || got != value {
+			t.Errorf("DecryptValue(%q) = %q, %v, want %q", enc, got, err, value)
+		}
+	}
+}
+
+func TestEncryptValueRandomIV(t *testing.T) {
+	a, err := EncryptValue("s3cret-pass", testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := EncryptValue("s3cret-pass", testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("the same plaintext is encrypted to the same value %q", a)
+	}
+}
+
+func TestDecryptValue(t *testing.T) {
+	tests := []struct {
+		value   string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{value: "ENC(v2:TqaUPDNSjlL3c4eZGSiomgCGci2BljE3TSvkmoO3deg=)", key: testKey, want: "s3cret-pass"},
+		{value: " ENC(v2:TqaUPDNSjlL3c4eZGSiomgCGci2BljE3TSvkmoO3deg=) ", key: testKey, want: "s3cret-pass"},
+		{value: "plain", key: testKey, want: "plain"},
+		{value: "ENC(v2:TqaUPDNSjlL3c4eZGSiomgCGci2BljE3TSvkmoO3deg=)", key: "fedcba9876543210", wantErr: true},
+		// 没有 v2: 前缀的固定 IV 格式不再支持
+		{value: "ENC(9zoN1e8ZLkDMRtHn/rNwUw==)", key: testKey, wantErr: true},
+		{value: "ENC(v2:9zoN1e8ZLkDMRtHn/rNwUw==)", key: testKey, wantErr: true},
+		{value: "ENC(v2:AAAA)", key: testKey, wantErr: true},
+		{value: "ENC(not base64)", key: testKey, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DecryptValue(tt.value, tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("DecryptValue(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DecryptValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package helper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+)
+
+// Unzip 解压 zip 到 dst 目录
+func Unzip(zipReader *zip.Reader, dst string) error {
+	e, err := newExtractor(dst)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+	// 遍历 zip 包里的文件
+	for _, file := range zipReader.File {
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			err = e.dir(file.Name)
+		case mode&fs.ModeSymlink != 0:
+			// zip 中软链接的内容为链接目标
+			var target []byte
+			if target, err = readZipFile(file); err == nil {
+				err = e.symlink(file.Name, string(target))
+			}
+		case mode.IsRegular():
+			var fr io.ReadCloser
+			if fr, err = file.Open(); err == nil {
+				err = e.file(file.Name, mode, fr)
+				_ = fr.Close()
+			}
+		default:
+			err = fmt.Errorf("unsupported zip entry %s with mode %s", file.Name, mode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.createLinks()
+}
+
+// Untar 解压 .tar.gz 到 dst 目录
+func Untar(r io.Reader, dst string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = gr.Close()
+	}()
+	e, err := newExtractor(dst)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return e.createLinks()
+		}
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = e.dir(header.Name)
+		case tar.TypeReg:
+			err = e.file(header.Name, header.FileInfo().Mode(), tr)
+		case tar.TypeSymlink:
+			err = e.symlink(header.Name, header.Linkname)
+		case tar.TypeXGlobalHeader:
+			// git archive 生成的全局 pax 头, 不是文件
+		default:
+			err = fmt.Errorf("unsupported tar entry %s with type %q", header.Name, header.Typeflag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// localName 压缩包中的路径转换为本地的相对路径
+func localName(name string) (string, error) {
+	name = filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("illegal path %q in archive", name)
+	}
+	return filepath.Clean(name), nil
+}
+
+// extractor 通过 os.Root 解压, 写入不会跟随软链接跳出目录;
+// 软链接在全部文件写入之后才创建, 避免后续条目经过软链接写到目录之外
+type extractor struct {
+	dst   string
+	root  *os.Root
+	links [][2]string // 待创建的软链接: 路径, 链接目标
+}
+
+func newExtractor(dst string) (*extractor, error) {
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(dst)
+	if err != nil {
+		return nil, err
+	}
+	return &extractor{dst: dst, root: root}, nil
+}
+
+func (e *extractor) close() {
+	_ = e.root.Close()
+}
+
+func (e *extractor) dir(name string) error {
+	name, err := localName(name)
+	if err != nil {
+		return err
+	}
+	return e.mkdirAll(name)
+}
+
+// mkdirAll 逐级创建目录, os.Root 在 Go 1.25 之前没有 MkdirAll
+func (e *extractor) mkdirAll(name string) error {
+	if name == "." {
+		return nil
+	}
+	if err := e.mkdirAll(filepath.Dir(name)); err != nil {
+		return err
+	}
+	if err := e.root.Mkdir(name, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+// file 写出文件, 权限统一为 0644, 原文件可执行时为 0755
+func (e *extractor) file(name string, mode fs.FileMode, r io.Reader) error {
+	name, err := localName(name)
+	if err != nil {
+		return err
+	}
+	// 部分压缩包不包含目录条目
+	if err = e.mkdirAll(filepath.Dir(name)); err != nil {
+		return err
+	}
+	perm := fs.FileMode(0o644)
+	if mode&0o111 != 0 {
+		perm = 0o755
+	}
+	fw, err := e.root.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(fw, r); err != nil {
+		_ = fw.Close()
+		return err
+	}
+	return fw.Close()
+}
+
+// symlink 记录软链接, 链接目标必须是 root 内的相对路径
+func (e *extractor) symlink(name, target string) error {
+	name, err := localName(name)
+	if err != nil {
+		return err
+	}
+	rel := filepath.Join(filepath.Dir(name), filepath.FromSlash(target))
+	if filepath.IsAbs(filepath.FromSlash(target)) || !filepath.IsLocal(rel) {
+		return fmt.Errorf("symlink %s -> %s escapes the project folder", name, target)
+	}
+	e.links = append(e.links, [2]string{name, target})
+	return nil
+}
+
+// createLinks 创建软链接, 上级目录不能是软链接, 创建后链接目标解析后仍须在 root 内
+func (e *extractor) createLinks() error {
+	for _, link := range e.links {
+		name := link[0]
+		if err := e.mkdirAll(filepath.Dir(name)); err != nil {
+			return err
+		}
+		for dir := filepath.Dir(name); dir != "."; dir = filepath.Dir(dir) {
+			if stat, err := e.root.Lstat(dir); err == nil && stat.Mode()&fs.ModeSymlink != 0 {
+				return fmt.Errorf("symlink %s -> %s is inside the symlink %s", name, link[1], dir)
+			}
+		}
+		if err := os.Symlink(link[1], filepath.Join(e.dst, name)); err != nil {
+			return err
+		}
+	}
+
+	// 软链接可能经过其他软链接跳出目录, 如 x -> . 和 esc -> x/..
+	root, err := filepath.EvalSymlinks(e.dst)
+	if err != nil {
+		return err
+	}
+	for _, link := range e.links {
+		path := filepath.Join(e.dst, link[0])
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			var rel string
+			if rel, err = filepath.Rel(root, resolved); err == nil && !filepath.IsLocal(rel) && rel != "." {
+				err = errors.New("escapes the project folder")
+			}
+		}
+		if err != nil {
+			_ = os.Remove(path)
+			return fmt.Errorf("symlink %s -> %s: %w", link[0], link[1], err)
+		}
+	}
+	return nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	fr, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = fr.Close()
+	}()
+	return io.ReadAll(fr)
+}
@@ -0,0 +1,194 @@
+package helper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type entry struct {
+	name   string
+	typ    byte // tar.TypeReg, tar.TypeDir 或 tar.TypeSymlink
+	body   string
+	target string
+	mode   int64
+}
+
+func tarGz(t *testing.T, entries []entry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		mode := e.mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		h := &tar.Header{Name: e.name, Typeflag: e.typ, Linkname: e.target, Mode: mode, Size: int64(len(e.body))}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestLocalName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "a/b.go", want: filepath.Join("a", "b.go")},
+		{name: "a/", want: "a"},
+		{name: "a/../b", want: "b"},
+		{name: "../a", wantErr: true},
+		{name: "a/../../b", wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := localName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("localName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("localName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUntar(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "out")
+	err := Untar(tarGz(t, []entry{
+		{name: "app/", typ: tar.TypeDir},
+		{name: "app/link.txt", typ: tar.TypeSymlink, target: "main.go"},
+		{name: "app/main.go", typ: tar.TypeReg, body: "package main"},
+		{name: "app/bin/run.sh", typ: tar.TypeReg, body: "#!/bin/sh", mode: 0o700},
+	}), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dst, "app", "link.txt"))
+	if err != nil || string(content) != "package main" {
+		t.Errorf("read symlink = %q, %v", content, err)
+	}
+	stat, err := os.Stat(filepath.Join(dst, "app", "bin", "run.sh"))
+	if err != nil || stat.Mode().Perm() != 0o755 {
+		t.Errorf("stat run.sh = %v, %v", stat, err)
+	}
+}
+
+func TestUntarEscape(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+	}{
+		{name: "parent path", entries: []entry{{name: "../pwned.txt", typ: tar.TypeReg}}},
+		{name: "absolute path", entries: []entry{{name: "/tmp/pwned.txt", typ: tar.TypeReg}}},
+		{name: "parent symlink", entries: []entry{{name: "a/esc", typ: tar.TypeSymlink, target: "../.."}}},
+		{name: "absolute symlink", entries: []entry{{name: "esc", typ: tar.TypeSymlink, target: "/tmp"}}},
+		{name: "write through symlink", entries: []entry{
+			{name: "a/up", typ: tar.TypeSymlink, target: ".."},
+			{name: "a/up/up/pwned.txt", typ: tar.TypeReg},
+		}},
+		// 每个软链接在字面上都在目录内, 组合起来指向目录之外
+		{name: "chained symlinks", entries: []entry{
+			{name: "x", typ: tar.TypeSymlink, target: "."},
+			{name: "esc", typ: tar.TypeSymlink, target: "x/.."},
+			{name: "esc/c", typ: tar.TypeSymlink, target: ".."},
+			{name: "esc/c/c2", typ: tar.TypeSymlink, target: ".."},
+			{name: "esc/c/c2/pwned.txt", typ: tar.TypeReg, body: "pwned"},
+		}},
+		{name: "symlink resolved outside", entries: []entry{
+			{name: "x", typ: tar.TypeSymlink, target: "."},
+			{name: "esc", typ: tar.TypeSymlink, target: "x/.."},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := filepath.Join(t.TempDir(), "a", "b")
+			dst := filepath.Join(base, "out")
+			if err := Untar(tarGz(t, tt.entries), dst); err == nil {
+				t.Fatal("Untar() error = nil, want error")
+			}
+			// 目录之外不能出现任何文件
+			err := filepath.WalkDir(filepath.Dir(filepath.Dir(base)), func(path string, d fs.DirEntry, err error) error {
+				if path == dst {
+					return filepath.SkipDir
+				}
+				if err == nil && d.Name() == "pwned.txt" {
+					t.Errorf("file written outside the destination: %s", path)
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestUnzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name, body string, mode fs.FileMode) {
+		h := &zip.FileHeader{Name: name}
+		h.SetMode(mode)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("app/main.go", "package main", 0o644)
+	add("app/link.go", "main.go", 0o777|fs.ModeSymlink)
+	add("app/esc", "..", 0o777|fs.ModeSymlink)
+	add("app/esc/pwned.txt", "pwned", 0o644)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "out")
+	if err = Unzip(zr, dst); err == nil {
+		t.Fatal("Unzip() error = nil, want error")
+	}
+	if _, err = os.Stat(filepath.Join(dst, "pwned.txt")); err == nil {
+		t.Error("file written through the symlink app/esc")
+	}
+}
+
+func TestUntarExistingSymlink(t *testing.T) {
+	outside := t.TempDir()
+	dst := t.TempDir()
+	// 目标目录中已有指向目录之外的软链接
+	if err := os.Symlink(outside, filepath.Join(dst, "pre")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Untar(tarGz(t, []entry{{name: "pre/pwned.txt", typ: tar.TypeReg}}), dst); err == nil {
+		t.Fatal("Untar() error = nil, want error")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "pwned.txt")); err == nil {
+		t.Error("file written through the existing symlink pre")
+	}
+}
@@ -0,0 +1,146 @@
+package helper
+
+import (
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+)
+
+// diffContext unified diff 中变更前后保留的行数
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ' 不变, '-' 删除, '+' 新增
+	text string
+}
+
+// UnifiedDiff 两段文本按行比较的 unified diff, 没有差异时为空
+func UnifiedDiff(oldName, newName string, a, b []byte) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var buf strings.Builder
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		// 间隔不超过 2*diffContext 的变更合并为一个 hunk
+		end := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k + 1
+			} else if k-end >= 2*diffContext {
+				break
+			}
+		}
+		begin, stop := max(first-diffContext, start), min(end+diffContext, len(ops))
+
+		oldLine, newLine := 0, 0
+		for _, op := range ops[:begin] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[begin:stop] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[begin:stop] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.text)
+			buf.WriteByte('\n')
+		}
+		start = stop
+	}
+	return buf.String()
+}
+
+// hunkRange 行数为 0 时起始行为前一行
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffLines 由最长公共子序列得到逐行的编辑操作, 删除在新增之前;
+// 相同的前缀和后缀不参与计算, LCS 表只包含中间不同的部分
+func diffLines(x, y []string) []diffOp {
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, max(len(x), len(y)))
+	for _, line := range x[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = lcsDiff(ops, x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])
+	for _, line := range x[len(x)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// lcsDiff 将 x 到 y 的编辑操作追加到 ops
+func lcsDiff(ops []diffOp, x, y []string) []diffOp {
+	n, m := len(x), len(y)
+	// lcs[i*(m+1)+j] 为 x[i:] 和 y[j:] 的最长公共子序列长度
+	lcs := make([]int, (n+1)*(m+1))
+	at := func(i, j int) int { return lcs[i*(m+1)+j] }
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i*(m+1)+j] = at(i+1, j+1) + 1
+			} else {
+				lcs[i*(m+1)+j] = max(at(i+1, j), at(i, j+1))
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && x[i] == y[j]:
+			ops = append(ops, diffOp{' ', x[i]})
+			i++
+			j++
+		case i < n && (j == m || at(i+1, j) >= at(i, j+1)):
+			ops = append(ops, diffOp{'-', x[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', y[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numbered 第 from 到 to 行, 每行为行号
+func numbered(from, to int) string {
+	var b strings.Builder
+	for i := from; i <= to; i++ {
+		b.WriteString(strconv.Itoa(i) + "\n")
+	}
+	return b.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "same", a: numbered(1, 5), b: numbered(1, 5)},
+		{name: "both empty"},
+		{
+			name: "create",
+			b:    "a\nb\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "remove all",
+			a:    "a\n",
+			want: "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "insertion",
+			a:    numbered(1, 10),
+			b:    numbered(1, 5) + "new\n" + numbered(6, 10),
+			want: "--- a\n+++ b\n@@ -3,6 +3,7 @@\n 3\n 4\n 5\n+new\n 6\n 7\n 8\n",
+		},
+		{
+			name: "deletion at the start",
+			a:    numbered(1, 10),
+			b:    numbered(3, 10),
+			want: "--- a\n+++ b\n@@ -1,5 +1,3 @@\n-1\n-2\n 3\n 4\n 5\n",
+		},
+		{
+			name: "insertion at the end",
+			a:    numbered(1, 5),
+			b:    numbered(1, 6),
+			want: "--- a\n+++ b\n@@ -3,3 +3,4 @@\n 3\n 4\n 5\n+6\n",
+		},
+		{
+			// 两处变更之间不超过 6 行时合并为一个 hunk
+			name: "merge hunks",
+			a:    numbered(1, 20),
+			b:    numbered(1, 2) + "x\n" + numbered(4, 9) + "y\n" + numbered(11, 20),
+			want: "--- a\n+++ b\n@@ -1,13 +1,13 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n 7\n 8\n 9\n-10\n+y\n 11\n 12\n 13\n",
+		},
+		{
+			name: "separate hunks",
+			a:    numbered(1, 20),
+			b:    numbered(1, 2) + "x\n" + numbered(4, 10) + "y\n" + numbered(12, 20),
+			want: "--- a\n+++ b\n@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+x\n 4\n 5\n 6\n@@ -8,7 +8,7 @@\n 8\n 9\n 10\n-11\n+y\n 12\n 13\n 14\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("a", "b", []byte(tt.a), []byte(tt.b)); got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
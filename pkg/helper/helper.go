@@ -1,11 +1,15 @@
 package helper
 
 import (
+	"crypto/rand"
+	"math/big"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 	"github.com/spruce1698/kun/pkg/fmt"
 )
@@ -81,3 +85,51 @@ func FindMain(base, excludeDir string) (map[string]string, error) {
 	}
 	return cmdPath, nil
 }
+
+// IsInteractive 标准输入是否为终端, 非终端(CI/管道)时不能使用交互式询问
+func IsInteractive() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// MatchPath 匹配 / 分隔的相对路径, 在 path.Match 基础上支持 ** 匹配任意层目录
+func MatchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filepath.ToSlash(name), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+const randomLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// RandomString 使用 crypto/rand 生成由字母和数字组成的随机字符串, 用于密钥和密码
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(randomLetters)))
+	for i := range b {
+		k, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = randomLetters[k.Int64()]
+	}
+	return string(b), nil
+}
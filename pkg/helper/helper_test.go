@@ -0,0 +1,33 @@
+package helper
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"cmd/*/main.go", "cmd/server/main.go", true},
+		{"cmd/*/main.go", "cmd/server/task/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "internal/a/b/c.go", true},
+		{"**/*.go", "internal/a/b/c.yml", false},
+		{"deploy/**", "deploy/docker/Dockerfile", true},
+		{"deploy/**", "deploy", true},
+		{"deploy/**", "deployment/a", false},
+		{"pkg/**/kafka.go", "pkg/kafka.go", true},
+		{"pkg/**/kafka.go", "pkg/mq/kafka/kafka.go", true},
+		{"config/*.yml", "config/local.yml", true},
+		{"config/*.yml", "config/local.yaml", false},
+		{"Makefile", "Makefile", true},
+		{"Makefile", "sub/Makefile", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+)
+
+// commonInitialisms 全部大写的常见缩写, ID 与 db repository 的命名一致写为 Id
+var commonInitialisms = map[string]string{
+	"ACL": "ACL", "API": "API", "ASCII": "ASCII", "CPU": "CPU", "CSS": "CSS", "CSRF": "CSRF", "DNS": "DNS",
+	"EOF": "EOF", "GUID": "GUID", "HTML": "HTML", "HTTP": "HTTP", "HTTPS": "HTTPS", "ID": "Id", "IP": "IP",
+	"JSON": "JSON", "JWT": "JWT", "LHS": "LHS", "OTP": "OTP", "QPS": "QPS", "RAM": "RAM", "RHS": "RHS",
+	"RPC": "RPC", "SKU": "SKU", "SLA": "SLA", "SMS": "SMS", "SMTP": "SMTP", "SQL": "SQL", "SSH": "SSH",
+	"TCP": "TCP", "TLS": "TLS", "TTL": "TTL", "UDP": "UDP", "UI": "UI", "UID": "UID", "URI": "URI",
+	"URL": "URL", "UTF8": "UTF8", "UUID": "UUID", "VM": "VM", "XML": "XML", "XSRF": "XSRF", "XSS": "XSS",
+}
+
+// SplitWords 按 _ - 空格和大小写拆分名称, 数字属于前一个单词, 如 APIKey -> API Key, user_id2 -> user id2
+func SplitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+	for i, r := range runes {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+			}
+			start = -1
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := runes[i-1]
+		// aB 或 ABc 中 B 是新单词的开头
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+			unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// CamelCase 大驼峰, 常见缩写全部大写, 如 user_profile -> UserProfile, api-key -> APIKey
+func CamelCase(s string) string {
+	var b strings.Builder
+	for _, word := range SplitWords(s) {
+		if initialism, ok := commonInitialisms[strings.ToUpper(word)]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// LowerCamelCase 小驼峰, 开头的缩写全部小写, 如 APIKey -> apiKey, UserId -> userId, json-api -> jsonAPI
+func LowerCamelCase(s string) string {
+	words := SplitWords(s)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0]) + CamelCase(strings.Join(words[1:], " "))
+}
+
+// GoName 将名称转换为大驼峰的 Go 标识符, 不是合法的标识符或小写后是关键字时返回错误
+func GoName(s string) (string, error) {
+	name := CamelCase(s)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("invalid name %q: empty", s)
+	case unicode.IsDigit([]rune(name)[0]):
+		return "", fmt.Errorf("invalid name %q: must not start with a digit", s)
+	case !token.IsIdentifier(name):
+		return "", fmt.Errorf("invalid name %q: only letters, digits, _, - and spaces are allowed", s)
+	case token.IsKeyword(LowerCamelCase(name)):
+		return "", fmt.Errorf("invalid name %q: %s is a Go keyword", s, LowerCamelCase(name))
+	}
+	return name, nil
+}
+
+// PackageName 校验目录名是否可以作为包名
+func PackageName(s string) error {
+	if !token.IsIdentifier(s) || token.IsKeyword(s) || s == "_" {
+		return fmt.Errorf("invalid package name %q: must be a Go identifier and not a keyword", s)
+	}
+	return nil
+}
+
+// SnakeCase 小写下划线分隔, 如 UserProfile -> user_profile, APIKey -> api_key
+func SnakeCase(s string) string {
+	return strings.ToLower(strings.Join(SplitWords(s), "_"))
+}
+
+// KebabCase 小写中划线分隔, 如 UserProfile -> user-profile
+func KebabCase(s string) string {
+	return strings.ToLower(strings.Join(SplitWords(s), "-"))
+}
@@ -0,0 +1,103 @@
+package helper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"user", []string{"user"}},
+		{"UserProfile", []string{"User", "Profile"}},
+		{"userProfile", []string{"user", "Profile"}},
+		{"user_profile", []string{"user", "profile"}},
+		{"user-profile", []string{"user", "profile"}},
+		{" user  profile ", []string{"user", "profile"}},
+		{"APIKey", []string{"API", "Key"}},
+		{"UserID", []string{"User", "ID"}},
+		{"user_id2", []string{"user", "id2"}},
+		{"OAuth2Token", []string{"O", "Auth2", "Token"}},
+		{"__", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := SplitWords(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCase(t *testing.T) {
+	tests := []struct {
+		in                              string
+		camel, lowerCamel, snake, kebab string
+	}{
+		{"user", "User", "user", "user", "user"},
+		{"user_profile", "UserProfile", "userProfile", "user_profile", "user-profile"},
+		{"UserProfile", "UserProfile", "userProfile", "user_profile", "user-profile"},
+		{"api key", "APIKey", "apiKey", "api_key", "api-key"},
+		{"user_id", "UserId", "userId", "user_id", "user-id"},
+		{"HTTPServer", "HTTPServer", "httpServer", "http_server", "http-server"},
+		{"json-api", "JSONAPI", "jsonAPI", "json_api", "json-api"},
+	}
+	for _, tt := range tests {
+		if got := CamelCase(tt.in); got != tt.camel {
+			t.Errorf("CamelCase(%q) = %q, want %q", tt.in, got, tt.camel)
+		}
+		if got := LowerCamelCase(tt.in); got != tt.lowerCamel {
+			t.Errorf("LowerCamelCase(%q) = %q, want %q", tt.in, got, tt.lowerCamel)
+		}
+		if got := SnakeCase(tt.in); got != tt.snake {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tt.in, got, tt.snake)
+		}
+		if got := KebabCase(tt.in); got != tt.kebab {
+			t.Errorf("KebabCase(%q) = %q, want %q", tt.in, got, tt.kebab)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "user", want: "User"},
+		{in: "user_profile", want: "UserProfile"},
+		{in: "api-key", want: "APIKey"},
+		{in: "order2", want: "Order2"},
+		{in: "", wantErr: true},
+		{in: "2fa", wantErr: true},
+		{in: "123abc", wantErr: true},
+		{in: "user$", wantErr: true},
+		{in: "user.name", wantErr: true},
+		{in: "type", wantErr: true},
+		{in: "Func", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := GoName(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GoName(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("GoName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for _, name := range []string{"admin", "v2", "user_profile"} {
+		if err := PackageName(name); err != nil {
+			t.Errorf("PackageName(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"admin-v2", "2fa", "type", "_", ""} {
+		if err := PackageName(name); err == nil {
+			t.Errorf("PackageName(%q) error = nil, want error", name)
+		}
+	}
+}
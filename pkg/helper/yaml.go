@@ -0,0 +1,257 @@
+package helper
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spruce1698/kun/pkg/fmt"
+	"gopkg.in/yaml.v3"
+)
+
+var plainScalarRegexp = regexp.MustCompile(`^[A-Za-z0-9_./+=()@:-]+$`)
+
+// YamlFile 按行修改 yaml 文件中的值, 保留原有的注释、空行和格式
+type YamlFile struct {
+	lines []string
+	root  *yaml.Node
+}
+
+// ParseYaml 解析 yaml, 顶层必须是 mapping
+func ParseYaml(data []byte) (*YamlFile, error) {
+	f := &YamlFile{lines: strings.Split(string(data), "\n")}
+	if err := f.parse(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *YamlFile) parse() error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(f.lines, "\n")), &doc); err != nil {
+		return err
+	}
+	switch {
+	case len(doc.Content) == 0:
+		f.root = &yaml.Node{Kind: yaml.MappingNode}
+	case doc.Content[0].Kind == yaml.MappingNode:
+		f.root = doc.Content[0]
+	default:
+		return fmt.Errorf("the top level of yaml must be a mapping")
+	}
+	return nil
+}
+
+// Bytes 修改后的内容
+func (f *YamlFile) Bytes() []byte {
+	return []byte(strings.Join(f.lines, "\n"))
+}
+
+// Root 顶层 mapping
+func (f *YamlFile) Root() *yaml.Node {
+	return f.root
+}
+
+// Lookup 按 . 分隔的路径查找值, 数组使用下标, 如 mysql.source.0
+func (f *YamlFile) Lookup(path string) *yaml.Node {
+	node := f.root
+	for _, key := range strings.Split(path, ".") {
+		if node = child(node, key); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+func child(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// childKey mapping 中 key 对应的键节点, 数组时为 nil
+func childKey(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Set 设置字符串值, 路径不存在时在最近的上级 mapping 末尾添加
+func (f *YamlFile) Set(path, value string) error {
+	return f.set(path, value, false)
+}
+
+// SetPlain 与 Set 相同, 但原值为 plain 风格时数字、布尔等不加引号, 按原样写入
+func (f *YamlFile) SetPlain(path, value string) error {
+	return f.set(path, value, true)
+}
+
+func (f *YamlFile) set(path, value string, plain bool) error {
+	keys := strings.Split(path, ".")
+	var key *yaml.Node
+	node := f.root
+	i := 0
+	for ; i < len(keys); i++ {
+		next := child(node, keys[i])
+		if next == nil {
+			break
+		}
+		key, node = childKey(node, keys[i]), next
+	}
+	if i == len(keys) {
+		if err := f.replaceScalar(key, node, value, plain); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return f.parse()
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: %s is not a mapping", path, strings.Join(keys[:i], "."))
+	}
+	if err := f.insert(node, keys[i:], value); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return f.parse()
+}
+
+// replaceScalar 替换单行标量在原文中的内容, 保持原来的引号风格
+func (f *YamlFile) replaceScalar(key, node *yaml.Node, value string, plain bool) error {
+	// key: 后面为空
+	if key != nil && node.Kind == yaml.ScalarNode && node.Tag == "!!null" && node.Value == "" {
+		line := []rune(f.lines[key.Line-1])
+		for i := key.Column - 1; i < len(line); i++ {
+			if line[i] == ':' {
+				rest := strings.TrimSpace(string(line[i+1:]))
+				if rest != "" {
+					rest = " " + rest
+				}
+				f.lines[key.Line-1] = string(line[:i+1]) + " " + QuoteYaml(value, yaml.DoubleQuotedStyle) + rest
+				return nil
+			}
+		}
+	}
+	if node.Kind != yaml.ScalarNode || node.Style&(yaml.LiteralStyle|yaml.FoldedStyle|yaml.FlowStyle) != 0 {
+		return fmt.Errorf("only single line scalar values can be changed")
+	}
+	line := []rune(f.lines[node.Line-1])
+	start := node.Column - 1
+	end, err := scalarEnd(line, start, node)
+	if err != nil {
+		return err
+	}
+	quoted := QuoteYaml(value, node.Style)
+	if plain && node.Style == 0 && plainScalarRegexp.MatchString(value) {
+		quoted = value
+	}
+	f.lines[node.Line-1] = string(line[:start]) + quoted + string(line[end:])
+	return nil
+}
+
+// scalarEnd 标量在行中的结束位置
+func scalarEnd(line []rune, start int, node *yaml.Node) (int, error) {
+	if start < 0 || start >= len(line) {
+		if node.Value == "" && node.Style == 0 {
+			return start, nil
+		}
+		return 0, fmt.Errorf("cannot locate the value at line %d", node.Line)
+	}
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\'' {
+				if i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1, nil
+			}
+		}
+	default:
+		end := start + len([]rune(node.Value))
+		if end <= len(line) && string(line[start:end]) == node.Value {
+			return end, nil
+		}
+	}
+	return 0, fmt.Errorf("only single line scalar values can be changed, line %d", node.Line)
+}
+
+// insert 在 mapping 的最后一行之后添加 keys 对应的层级和值
+func (f *YamlFile) insert(mapping *yaml.Node, keys []string, value string) error {
+	if mapping.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("flow mapping is not supported")
+	}
+	indent := 0
+	after := len(f.lines)
+	if len(mapping.Content) > 0 {
+		indent = mapping.Content[0].Column - 1
+		after = lastLine(mapping)
+	} else if mapping != f.root {
+		return fmt.Errorf("empty mapping is not supported")
+	}
+	// 文件末尾的空行之前
+	for after > 0 && mapping == f.root && strings.TrimSpace(f.lines[after-1]) == "" {
+		after--
+	}
+
+	lines := make([]string, 0, len(keys))
+	for i, key := range keys {
+		prefix := strings.Repeat(" ", indent+2*i) + key + ":"
+		if i == len(keys)-1 {
+			prefix += " " + QuoteYaml(value, yaml.DoubleQuotedStyle)
+		}
+		lines = append(lines, prefix)
+	}
+	f.lines = append(f.lines[:after], append(lines, f.lines[after:]...)...)
+	return nil
+}
+
+// lastLine 节点及其子节点的最后一行
+func lastLine(node *yaml.Node) int {
+	line := node.Line
+	for _, c := range node.Content {
+		if l := lastLine(c); l > line {
+			line = l
+		}
+	}
+	return line
+}
+
+// QuoteYaml 按指定风格输出字符串, plain 风格无法表示时使用双引号
+func QuoteYaml(value string, style yaml.Style) string {
+	switch {
+	case style&yaml.SingleQuotedStyle != 0:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case style&yaml.DoubleQuotedStyle == 0 && plainScalarRegexp.MatchString(value):
+		// 会被解析为数字/布尔等非字符串时仍需要引号
+		var v any
+		if err := yaml.Unmarshal([]byte(value), &v); err == nil {
+			if _, ok := v.(string); ok {
+				return value
+			}
+		}
+	}
+	return strconv.Quote(value)
+}
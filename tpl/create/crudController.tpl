@@ -0,0 +1,144 @@
+package {{ .PackageName }}
+
+import (
+	"{{ .ProjectName }}/internal/service/svc"
+	"{{ .ProjectName }}/pkg/xerror"
+	"{{ .ProjectName }}/pkg/xhttp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+)
+
+type (
+	{{ .FileName }}Ctrl struct {
+		{{ .FileName }}Svc svc.{{ .FileName }}Svc
+	}
+
+	// {{ .FileNameTitleLower }}Id 路径中的id
+	{{ .FileNameTitleLower }}Id struct {
+		Id {{ .Crud.PkType }} `uri:"id" binding:"required,min=1"`
+	}
+
+	// {{ .FileName }}ListReq 列表请求
+	{{ .FileName }}ListReq struct {
+		xhttp.PageArg
+	}
+
+	// {{ .FileName }}Req 创建/修改请求
+	{{ .FileName }}Req struct {
+{{- range .Crud.EditableFields }}
+		{{ .Name }} {{ .Type }} `json:"{{ .JSON }}"{{ if .Required }} binding:"required"{{ end }}`{{ if .Comment }} // {{ .Comment }}{{ end }}
+{{- end }}
+	}
+)
+
+// @Summary List
+// @Description {{ .FileName }}列表
+// @Tags {{ .FileNameTitleLower }}
+// @Produce json
+// @Router /api/{{ .FileNameTitleLower }} [get]
+func ({{ .FileNameFirstChar }} *{{ .FileName }}Ctrl) List(ctx *gin.Context) {
+	req := &{{ .FileName }}ListReq{}
+	if err := ctx.ShouldBind(req); err != nil {
+		xhttp.BusCode(ctx, xerror.ParamError, err)
+		return
+	}
+	args := &svc.{{ .FileName }}ListArgs{}
+	_ = copier.Copy(args, req)
+
+	result, total, err := {{ .FileNameFirstChar }}.{{ .FileName }}Svc.List(ctx.Request.Context(), args)
+	if err != nil {
+		xhttp.BusFail(ctx, err)
+		return
+	}
+	xhttp.List(ctx, "Get {{ .FileName }} List 成功", total, req.Page, req.PageSize, result)
+}
+
+// @Summary Detail
+// @Description {{ .FileName }}详情
+// @Tags {{ .FileNameTitleLower }}
+// @Produce json
+// @Param id path int true "id"
+// @Router /api/{{ .FileNameTitleLower }}/{id} [get]
+func ({{ .FileNameFirstChar }} *{{ .FileName }}Ctrl) Detail(ctx *gin.Context) {
+	uri := &{{ .FileNameTitleLower }}Id{}
+	if err := ctx.ShouldBindUri(uri); err != nil {
+		xhttp.BusCode(ctx, xerror.ParamError, err)
+		return
+	}
+	info, err := {{ .FileNameFirstChar }}.{{ .FileName }}Svc.Detail(ctx.Request.Context(), uri.Id)
+	if err != nil {
+		xhttp.BusFail(ctx, err)
+		return
+	}
+	xhttp.Data(ctx, "Get {{ .FileName }} Detail 成功", info)
+}
+
+// @Summary Create
+// @Description 创建{{ .FileName }}
+// @Tags {{ .FileNameTitleLower }}
+// @Accept json
+// @Produce json
+// @Router /api/{{ .FileNameTitleLower }} [post]
+func ({{ .FileNameFirstChar }} *{{ .FileName }}Ctrl) Create(ctx *gin.Context) {
+	req := &{{ .FileName }}Req{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		xhttp.BusCode(ctx, xerror.ParamError, err)
+		return
+	}
+	args := &svc.{{ .FileName }}{}
+	_ = copier.Copy(args, req)
+	id, err := {{ .FileNameFirstChar }}.{{ .FileName }}Svc.Create(ctx.Request.Context(), args)
+	if err != nil {
+		xhttp.BusFail(ctx, err)
+		return
+	}
+	xhttp.Data(ctx, "Create {{ .FileName }} 成功", gin.H{"id": id})
+}
+
+// @Summary Update
+// @Description 修改{{ .FileName }}
+// @Tags {{ .FileNameTitleLower }}
+// @Accept json
+// @Produce json
+// @Param id path int true "id"
+// @Router /api/{{ .FileNameTitleLower }}/{id} [put]
+func ({{ .FileNameFirstChar }} *{{ .FileName }}Ctrl) Update(ctx *gin.Context) {
+	uri := &{{ .FileNameTitleLower }}Id{}
+	if err := ctx.ShouldBindUri(uri); err != nil {
+		xhttp.BusCode(ctx, xerror.ParamError, err)
+		return
+	}
+	req := &{{ .FileName }}Req{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		xhttp.BusCode(ctx, xerror.ParamError, err)
+		return
+	}
+	args := &svc.{{ .FileName }}{}
+	_ = copier.Copy(args, req)
+	args.Id = uri.Id
+	if err := {{ .FileNameFirstChar }}.{{ .FileName }}Svc.Update(ctx.Request.Context(), args); err != nil {
+		xhttp.BusFail(ctx, err)
+		return
+	}
+	xhttp.Data(ctx, "Update {{ .FileName }} 成功", nil)
+}
+
+// @Summary Delete
+// @Description 删除{{ .FileName }}
+// @Tags {{ .FileNameTitleLower }}
+// @Produce json
+// @Param id path int true "id"
+// @Router /api/{{ .FileNameTitleLower }}/{id} [delete]
+func ({{ .FileNameFirstChar }} *{{ .FileName }}Ctrl) Delete(ctx *gin.Context) {
+	uri := &{{ .FileNameTitleLower }}Id{}
+	if err := ctx.ShouldBindUri(uri); err != nil {
+		xhttp.BusCode(ctx, xerror.ParamError, err)
+		return
+	}
+	if err := {{ .FileNameFirstChar }}.{{ .FileName }}Svc.Delete(ctx.Request.Context(), uri.Id); err != nil {
+		xhttp.BusFail(ctx, err)
+		return
+	}
+	xhttp.Data(ctx, "Delete {{ .FileName }} 成功", nil)
+}
@@ -0,0 +1,22 @@
+package {{ .PackageName }}
+
+import (
+	"{{ .ProjectName }}/internal/controller"
+	"{{ .ProjectName }}/internal/global"
+	"{{ .ProjectName }}/internal/middleware"
+	"{{ .ProjectName }}/pkg/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func {{ .FileName }}(e *gin.Engine, jwt *token.Jwt, ctx *controller.ServerCtrlCtx) {
+	// api {{ .FileName }}路由, 需要登录
+	apiGroup := e.Group(global.RouterPrefixApi).Use(middleware.Auth(jwt))
+	{
+		apiGroup.GET("/{{ .FileNameTitleLower }}", ctx.{{ .FileName }}Ctrl.List)
+		apiGroup.GET("/{{ .FileNameTitleLower }}/:id", ctx.{{ .FileName }}Ctrl.Detail)
+		apiGroup.POST("/{{ .FileNameTitleLower }}", ctx.{{ .FileName }}Ctrl.Create)
+		apiGroup.PUT("/{{ .FileNameTitleLower }}/:id", ctx.{{ .FileName }}Ctrl.Update)
+		apiGroup.DELETE("/{{ .FileNameTitleLower }}/:id", ctx.{{ .FileName }}Ctrl.Delete)
+	}
+}
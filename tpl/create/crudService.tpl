@@ -0,0 +1,139 @@
+package {{ .PackageName }}
+
+import (
+	"context"
+
+	"{{ .ProjectName }}/internal/repository/db"
+	"{{ .ProjectName }}/pkg/xerror"
+
+	"github.com/jinzhu/copier"
+	"github.com/pkg/errors"
+)
+
+//go:generate mockgen -source=./{{ .FileNameTitleLower }}.go -destination=../../../test/mocks/service/{{ .FileNameTitleLower }}.go  -package mock_service
+
+var _ {{ .FileName }}Svc = (*{{ .FileNameTitleLower }}Svc)(nil)
+
+type (
+	{{ .FileName }}Svc interface {
+		List(ctx context.Context, args *{{ .FileName }}ListArgs) ([]*{{ .FileName }}, int64, error)
+		Detail(ctx context.Context, id {{ .Crud.PkType }}) (*{{ .FileName }}, error)
+		Create(ctx context.Context, args *{{ .FileName }}) ({{ .Crud.PkType }}, error)
+		Update(ctx context.Context, args *{{ .FileName }}) error
+		Delete(ctx context.Context, id {{ .Crud.PkType }}) error
+	}
+
+	{{ .FileName }}Ctx struct {
+		*Ctx
+
+		{{ .FileName }}Db db.{{ .FileName }}Db
+	}
+
+	{{ .FileNameTitleLower }}Svc struct {
+		ctx *{{ .FileName }}Ctx
+	}
+
+	{{ .FileName }}ListArgs struct {
+		OrderField string // 排序字段
+		OrderType  int64  // 排序类型 0:升序,1:降序
+		Page       int64  // 当前页
+		PageSize   int64  // 每页条数
+		LastId     int64  // 上一页最大id
+	}
+
+	// {{ .FileName }} mapped from table <{{ .Crud.Table }}>
+	{{ .FileName }} struct {
+{{- range .Crud.Fields }}
+		{{ .Name }} {{ .Type }} `json:"{{ .JSON }}"`{{ if .Comment }} // {{ .Comment }}{{ end }}
+{{- end }}
+	}
+)
+
+func New{{ .FileName }}Svc(ctx *{{ .FileName }}Ctx) {{ .FileName }}Svc {
+	return &{{ .FileNameTitleLower }}Svc{
+		ctx: ctx,
+	}
+}
+
+// 分页查询列表
+func ({{ .FileNameFirstChar }} *{{ .FileNameTitleLower }}Svc) List(ctx context.Context, args *{{ .FileName }}ListArgs) ([]*{{ .FileName }}, int64, error) {
+	search := &db.{{ .FileName }}Search{}
+	_ = copier.Copy(search, args)
+
+	list, total, err := {{ .FileNameFirstChar }}.ctx.{{ .FileName }}Db.{{ .Crud.List }}(ctx, search)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return []*{{ .FileName }}{}, 0, nil
+		}
+		return nil, 0, xerror.NewError(ctx, xerror.BusinessError, "Get {{ .FileName }} List 失败", err)
+	}
+	result := make([]*{{ .FileName }}, 0, len(list))
+	for _, row := range list {
+		item := &{{ .FileName }}{}
+		_ = copier.Copy(item, row)
+		result = append(result, item)
+	}
+	return result, total, nil
+}
+
+// 查找一个
+func ({{ .FileNameFirstChar }} *{{ .FileNameTitleLower }}Svc) Detail(ctx context.Context, id {{ .Crud.PkType }}) (*{{ .FileName }}, error) {
+	row, err := {{ .FileNameFirstChar }}.ctx.{{ .FileName }}Db.{{ .Crud.Find }}(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, xerror.NewError(ctx, xerror.BusinessError, "没有相关记录", err)
+		}
+		return nil, xerror.NewError(ctx, xerror.BusinessError, "Get {{ .FileName }} Detail 失败", err)
+	}
+	result := &{{ .FileName }}{}
+	_ = copier.Copy(result, row)
+	return result, nil
+}
+
+// 创建
+func ({{ .FileNameFirstChar }} *{{ .FileNameTitleLower }}Svc) Create(ctx context.Context, args *{{ .FileName }}) ({{ .Crud.PkType }}, error) {
+	row := &db.{{ .FileName }}{
+{{- range .Crud.EditableFields }}
+		{{ .Name }}: args.{{ .Name }},
+{{- end }}
+	}
+	id, err := {{ .FileNameFirstChar }}.ctx.{{ .FileName }}Db.Insert(ctx, row)
+	if err != nil {
+		return 0, xerror.NewError(ctx, xerror.BusinessError, "Create {{ .FileName }} 失败", err)
+	}
+	return id, nil
+}
+
+// 修改, 零值也会更新
+func ({{ .FileNameFirstChar }} *{{ .FileNameTitleLower }}Svc) Update(ctx context.Context, args *{{ .FileName }}) error {
+	if args.Id <= 0 {
+		return xerror.NewError(ctx, xerror.InvalidArgument, "Update {{ .FileName }} invalid id", nil)
+	}
+	row := &db.{{ .FileName }}{
+		Id: args.Id,
+{{- range .Crud.EditableFields }}
+		{{ .Name }}: args.{{ .Name }},
+{{- end }}
+	}
+	affected, err := {{ .FileNameFirstChar }}.ctx.{{ .FileName }}Db.Update(ctx, row, []string{ {{- .Crud.Columns -}} })
+	if err != nil {
+		return xerror.NewError(ctx, xerror.BusinessError, "Update {{ .FileName }} 失败", err)
+	}
+	if affected == 0 {
+		if _, err = {{ .FileNameFirstChar }}.ctx.{{ .FileName }}Db.{{ .Crud.Find }}(ctx, args.Id); errors.Is(err, db.ErrNotFound) {
+			return xerror.NewError(ctx, xerror.BusinessError, "没有相关记录", err)
+		}
+	}
+	return nil
+}
+
+// 删除
+func ({{ .FileNameFirstChar }} *{{ .FileNameTitleLower }}Svc) Delete(ctx context.Context, id {{ .Crud.PkType }}) error {
+	if id <= 0 {
+		return xerror.NewError(ctx, xerror.InvalidArgument, "Delete {{ .FileName }} invalid id", nil)
+	}
+	if err := {{ .FileNameFirstChar }}.ctx.{{ .FileName }}Db.{{ .Crud.Remove }}(ctx, []{{ .Crud.PkType }}{id}); err != nil {
+		return xerror.NewError(ctx, xerror.BusinessError, "Delete {{ .FileName }} 失败", err)
+	}
+	return nil
+}